@@ -24,6 +24,9 @@ func RegisterRoutes() *mux.Router {
 	apiV1.Handle("/user/linkedin-callback",
 		middlewares.IPRateLimitMiddleware(30, time.Minute)(http.HandlerFunc(handlers.LinkedCallbackHandler)),
 	).Methods(http.MethodGet, http.MethodOptions)
+	apiV1.Handle("/user/mastodon-callback",
+		middlewares.IPRateLimitMiddleware(30, time.Minute)(http.HandlerFunc(handlers.MastodonCallbackHandler)),
+	).Methods(http.MethodGet, http.MethodOptions)
 	apiV1.Handle("/user/forgot-password",
 		middlewares.IPRateLimitMiddleware(5, time.Minute)(http.HandlerFunc(handlers.ForgotPasswordHandler)),
 	).Methods(http.MethodPost, http.MethodOptions)
@@ -48,6 +51,38 @@ func RegisterRoutes() *mux.Router {
 		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.ClearUserNotificationsHandler)),
 	).Methods(http.MethodDelete, http.MethodOptions)
 
+	apiV1.Handle("/user/catch-up-policy",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdateCatchUpPolicyHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
+	apiV1.Handle("/user/publishing-rules",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdatePublishingRulesHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
+	apiV1.Handle("/user/scheduled-blogs/release",
+		middlewares.AuthMiddleware(40, time.Minute, http.HandlerFunc(handlers.ReleaseHeldBlogHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/timezone",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdateTimezoneHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
+	apiV1.Handle("/user/posting-slots",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdatePostingScheduleHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
+	apiV1.Handle("/user/queue",
+		middlewares.AuthMiddleware(60, time.Minute, http.HandlerFunc(handlers.GetQueueHandler)),
+	).Methods(http.MethodGet, http.MethodOptions)
+
+	apiV1.Handle("/blogs/queue",
+		middlewares.AuthMiddleware(6, time.Minute, http.HandlerFunc(handlers.QueueBlogHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/share-runs",
+		middlewares.AuthMiddleware(60, time.Minute, http.HandlerFunc(handlers.GetShareRunsHandler)),
+	).Methods(http.MethodGet, http.MethodOptions)
+
 	apiV1.Handle("/blogs/schedule",
 		middlewares.AuthMiddleware(6, time.Minute, http.HandlerFunc(handlers.ScheduleBlogHandler)),
 	).Methods(http.MethodPost, http.MethodOptions)
@@ -60,6 +95,10 @@ func RegisterRoutes() *mux.Router {
 		middlewares.AuthMiddleware(40, time.Minute, http.HandlerFunc(handlers.CancelScheduledBlogHandler)),
 	).Methods(http.MethodDelete, http.MethodOptions)
 
+	apiV1.Handle("/user/scheduled-blogs/reschedule",
+		middlewares.AuthMiddleware(40, time.Minute, http.HandlerFunc(handlers.RescheduleBlogHandler)),
+	).Methods(http.MethodPatch, http.MethodOptions)
+
 	apiV1.Handle("/user/connect-twitter",
 		middlewares.AuthMiddleware(15, time.Minute, http.HandlerFunc(handlers.ConnectXhandler)),
 	).Methods(http.MethodGet, http.MethodOptions)
@@ -68,10 +107,50 @@ func RegisterRoutes() *mux.Router {
 		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.XcallbackHandler)),
 	).Methods(http.MethodGet, http.MethodOptions)
 
+	apiV1.Handle("/user/x-thread-settings",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdateXThreadSettingsHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
 	apiV1.Handle("/user/connect-linkedin",
 		middlewares.AuthMiddleware(15, time.Minute, http.HandlerFunc(handlers.ConnectLinkedInHandler)),
 	).Methods(http.MethodGet, http.MethodOptions)
 
+	apiV1.Handle("/user/connect-mastodon",
+		middlewares.AuthMiddleware(15, time.Minute, http.HandlerFunc(handlers.ConnectMastodonHandler)),
+	).Methods(http.MethodGet, http.MethodOptions)
+
+	apiV1.Handle("/user/connect-bluesky",
+		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.ConnectBlueskyHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/connect-telegram",
+		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.ConnectTelegramHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/connect-devto",
+		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.ConnectDevtoHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/cross-posts",
+		middlewares.AuthMiddleware(60, time.Minute, http.HandlerFunc(handlers.GetCrossPostsHandler)),
+	).Methods(http.MethodGet, http.MethodOptions)
+
+	apiV1.Handle("/blogs/cross-post/devto",
+		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.CrossPostDevtoHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/webhook",
+		middlewares.AuthMiddleware(10, time.Minute, http.HandlerFunc(handlers.UpdateWebhookHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
+	apiV1.Handle("/user/webhook/test",
+		middlewares.AuthMiddleware(5, time.Minute, http.HandlerFunc(handlers.TestWebhookHandler)),
+	).Methods(http.MethodPost, http.MethodOptions)
+
+	apiV1.Handle("/user/mastodon-settings",
+		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.UpdateMastodonSettingsHandler)),
+	).Methods(http.MethodPut, http.MethodOptions)
+
 	apiV1.Handle("/user/getinfo", middlewares.AuthMiddleware(15, time.Minute, http.HandlerFunc(handlers.GetUserInfoHandler))).Methods(http.MethodGet, http.MethodOptions)
 
 	apiV1.Handle("/user/verify-hashnode",
@@ -89,5 +168,10 @@ func RegisterRoutes() *mux.Router {
 		middlewares.AuthMiddleware(20, time.Minute, http.HandlerFunc(handlers.DeleteAccountHandler)),
 	).Methods(http.MethodDelete, http.MethodOptions)
 
+	// Admin routes for operators, authenticated with the ADMIN_TOKEN
+	apiV1.Handle("/admin/scheduler",
+		middlewares.IPRateLimitMiddleware(30, time.Minute)(middlewares.AdminMiddleware(http.HandlerFunc(handlers.SchedulerStatsHandler))),
+	).Methods(http.MethodGet, http.MethodOptions)
+
 	return router
 }
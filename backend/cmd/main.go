@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,10 +13,18 @@ import (
 	repo "social-scribe/backend/internal/repositories"
 	"social-scribe/backend/internal/scheduler"
 	"syscall"
+	"time"
+	// user timezones are loaded by name, the runtime image has no zoneinfo of its own
+	_ "time/tzdata"
 
 	"github.com/rs/cors"
 )
 
+const (
+	serverShutdownTimeout = 10 * time.Second
+	defaultDrainTimeout   = 20 * time.Second
+)
+
 func setupCors() *cors.Cors {
 	return cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:5173", "http://192.168.29.3:9696", "http://192.168.29.3:5173"},
@@ -24,6 +34,16 @@ func setupCors() *cors.Cors {
 	})
 }
 
+// drainTimeout is how long shutdown waits for running shares before putting them back
+// on the queue.
+func drainTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("SCHEDULER_DRAIN_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return defaultDrainTimeout
+	}
+	return timeout
+}
+
 func main() {
 	repo.InitMongoDb()
 	repo.InitRedis()
@@ -33,26 +53,48 @@ func main() {
 		hostname = "MISSING"
 	}
 
-	taskScheduler := scheduler.NewScheduler()
+	// the redis backend lets several backend replicas share the scheduled tasks
+	var taskScheduler *scheduler.Scheduler
+	if os.Getenv("SCHEDULER_BACKEND") == "redis" {
+		log.Println("[INFO] Using the Redis backed scheduler")
+		taskScheduler = scheduler.NewDistributedScheduler(repo.RedisClient)
+	} else {
+		taskScheduler = scheduler.NewScheduler()
+	}
 	handlers.InitScheduler(taskScheduler)
-	defer taskScheduler.Stop()
-
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-stop
-		log.Println("[INFO] Shutting down gracefully...")
-		taskScheduler.Stop()
-		os.Exit(0)
-	}()
 
 	corsHandler := setupCors()
 	port := os.Getenv("BACKEND_PORT")
 	if port == "" {
-		log.Printf("[DEBUG] Running on %s:9696", hostname)
-		log.Fatal(http.ListenAndServe(":9696", corsHandler.Handler(router)))
-	} else {
+		port = "9696"
+	}
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: corsHandler.Handler(router),
+	}
+	go func() {
 		log.Printf("[DEBUG] Running on %s:%s", hostname, port)
-		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), corsHandler.Handler(router)))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	log.Println("[INFO] Shutting down gracefully...")
+
+	// stop taking requests first so no new shares get scheduled while we drain
+	serverCtx, cancelServer := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancelServer()
+	if err := server.Shutdown(serverCtx); err != nil {
+		log.Printf("[ERROR] Error shutting down the HTTP server: %v", err)
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout())
+	defer cancelDrain()
+	if err := taskScheduler.Shutdown(drainCtx); err != nil {
+		log.Printf("[WARN] Scheduler didn't drain in time: %v", err)
 	}
+	log.Println("[INFO] Shutdown complete")
 }
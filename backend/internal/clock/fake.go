@@ -0,0 +1,112 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to, its timers fire when Advance or Set
+// passes their deadline.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, timers: make(map[*fakeTimer]struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward and fires the timers that came due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to now and fires the timers that came due.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+	for t := range f.timers {
+		if !t.deadline.After(now) {
+			delete(f.timers, t)
+			t.fire(now)
+		}
+	}
+}
+
+// Armed returns the deadlines of the timers that haven't fired or been stopped.
+func (f *Fake) Armed() []time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deadlines := make([]time.Time, 0, len(f.timers))
+	for t := range f.timers {
+		deadlines = append(deadlines, t.deadline)
+	}
+	return deadlines
+}
+
+// WaitForTimer waits until a timer is armed for the deadline, so a test knows the code
+// under test got to sleep before it advances the clock. It gives up after timeout.
+func (f *Fake) WaitForTimer(deadline time.Time, timeout time.Duration) bool {
+	giveUp := time.Now().Add(timeout)
+	for time.Now().Before(giveUp) {
+		for _, armed := range f.Armed() {
+			if armed.Equal(deadline) {
+				return true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+type fakeTimer struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, armed := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return armed
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, armed := t.clock.timers[t]
+	t.deadline = t.clock.now.Add(d)
+	if d <= 0 {
+		delete(t.clock.timers, t)
+		t.fire(t.clock.now)
+		return armed
+	}
+	t.clock.timers[t] = struct{}{}
+	return armed
+}
+
+// fire sends on the channel without blocking, like a time.Timer nobody reads from.
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/mail"
 	"os"
+	"strconv"
 
 	"social-scribe/backend/internal/middlewares"
 	"social-scribe/backend/internal/models"
@@ -37,6 +38,7 @@ import (
 var twitterConfig = &oauth1.Config{}
 var linkedinConfig = &oauth2.Config{}
 var frontendURL = os.Getenv("FRONTEND_URL")
+var mastodonCallbackURL = os.Getenv("MASTODON_CALLBACK_URL")
 var taskScheduler *scheduler.Scheduler
 
 func init() {
@@ -66,6 +68,8 @@ func init() {
 		Endpoint:     linkedin.Endpoint,
 	}
 
+	mastodonCallbackURL = os.Getenv("MASTODON_CALLBACK_URL")
+
 	services.InitTwitterConfig(twitterConfig)
 
 }
@@ -124,6 +128,11 @@ func SignupUserHandler(resp http.ResponseWriter, req *http.Request) {
 	user.EmailVerified = false
 	user.HashnodeVerified = false
 	user.XVerified = false
+	user.MastodonVerified = false
+	user.BlueskyVerified = false
+	user.TelegramVerified = false
+	user.DevtoVerified = false
+	user.WebHookUrl = ""
 	user.PassWord = string(hashedPassword)
 
 	userId, err := repo.InsertUser(user)
@@ -163,17 +172,7 @@ func SignupUserHandler(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 	message := fmt.Sprintf("Your OTP is: %s \n Valid for next 24 hours", otp)
-	// this is just a temporary work around to use the existing heap based queue system
-	// to send emails asynchronously and we need a better way to do this in the future
-	emailTask := models.ScheduledBlogData{}
-	shareTime := models.ScheduledBlog{}
-	shareTime.ScheduledTime = time.Now()
-	emailTask.EmailId = user.UserName
-	emailTask.Message = message
-	emailTask.ScheduledBlog = shareTime
-	emailTask.UserID = userId
-
-	err = taskScheduler.AddTask(emailTask)
+	err = taskScheduler.AddJob(models.NewEmailJob(userId, user.UserName, message, taskScheduler.Clock().Now()))
 	if err != nil {
 		log.Printf("[ERROR] Failed adding email task to the queue, reason: %s", err)
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -333,6 +332,11 @@ func GetUserInfoHandler(resp http.ResponseWriter, req *http.Request) {
 	user.LinkedInOauthKey = ""
 	user.XOAuthToken = ""
 	user.XOAuthSecret = ""
+	user.MastodonAccessToken = ""
+	user.BlueskyAppPassword = ""
+	user.TelegramBotToken = ""
+	user.DevtoApiKey = ""
+	user.WebHookUrl = services.MaskWebhookURL(user.WebHookUrl)
 
 	responseJson, err := json.Marshal(user)
 	if err != nil {
@@ -416,6 +420,342 @@ func ClearUserNotificationsHandler(resp http.ResponseWriter, req *http.Request)
 	resp.Write([]byte(`{"success" : true, "message" : "notifications cleared sucessfully"}`))
 }
 
+// UpdateCatchUpPolicyHandler sets what happens to the user's shares that are due while
+// the backend is down, scheduled blogs with their own catch_up keep it.
+func UpdateCatchUpPolicyHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var policy models.CatchUpPolicy
+	if err := json.NewDecoder(req.Body).Decode(&policy); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.CatchUpPolicy = &policy
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// UpdateTimezoneHandler sets the IANA timezone wall clock times of the user are in.
+// Blogs scheduled already keep the zone they were scheduled in.
+func UpdateTimezoneHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var requestBody struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	location, err := models.LoadTimezone(requestBody.Timezone)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.Timezone = location.String()
+	// slots without their own zone follow the user's, so the queue moves with it
+	if user.PostingSchedule != nil && user.PostingSchedule.Timezone == "" {
+		if err := taskScheduler.RepackQueue(userId, user); err != nil {
+			log.Printf("[ERROR] Failed to repack the queue of user with id: %s and error is %s", userId, err)
+			http.Error(resp, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// UpdatePublishingRulesHandler replaces the user's quiet hours and blackout dates, they
+// apply to shares that come due from now on.
+func UpdatePublishingRulesHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var rules models.PublishingRules
+	if err := json.NewDecoder(req.Body).Decode(&rules); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if rules.Action == "" {
+		rules.Action = models.BlackoutDefer
+	}
+	if err := rules.Validate(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.PublishingRules = &rules
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// ReleaseHeldBlogHandler lets the held targets of a scheduled blog go out right away.
+func ReleaseHeldBlogHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var requestBody struct {
+		TaskId string `json:"task_id"`
+		Id     string `json:"id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.TaskId == "" && requestBody.Id == "" {
+		http.Error(resp, "Missing task id", http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	index, status := findUserSchedule(user, requestBody.TaskId, requestBody.Id)
+	if status == http.StatusConflict {
+		http.Error(resp, "Blog is scheduled more than once, release it by its task id", http.StatusConflict)
+		return
+	}
+	if index < 0 {
+		http.Error(resp, "Scheduled blog not found", http.StatusNotFound)
+		return
+	}
+
+	scheduled := &user.ScheduledBlogs[index]
+	released, err := taskScheduler.ReleaseHeld(userId, *scheduled)
+	for i := range scheduled.Targets {
+		for _, platform := range released {
+			if scheduled.Targets[i].Platform == platform {
+				scheduled.Targets[i].Status = ""
+				scheduled.Targets[i].Result = "released"
+			}
+		}
+	}
+	if len(released) > 0 && scheduled.Status == models.TaskStatusHeld {
+		scheduled.Status = ""
+	}
+	if updErr := repo.UpdateUser(userId, user); updErr != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, updErr)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to release held blog %s and error is %s", scheduled.TaskKey(), err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(released) == 0 {
+		http.Error(resp, "Scheduled blog has nothing held", http.StatusConflict)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"success": true, "released": released})
+}
+
+// UpdatePostingScheduleHandler replaces the user's weekly posting slots, the queued
+// blogs are repacked into the new slots.
+func UpdatePostingScheduleHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var schedule models.PostingSchedule
+	if err := json.NewDecoder(req.Body).Decode(&schedule); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.PostingSchedule = &schedule
+	err = taskScheduler.RepackQueue(userId, user)
+	if errors.Is(err, scheduler.ErrNoPostingSlots) {
+		http.Error(resp, fmt.Sprintf("%s, queued blogs still need it", err), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to repack the queue of user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// GetQueueHandler returns the user's posting slots, the queued blogs in the order they
+// go out and the next free slot of every platform.
+func GetQueueHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+
+	queue := scheduler.QueuedBlogs(user)
+	if queue == nil {
+		queue = []models.ScheduledBlog{}
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{
+		"posting_schedule": user.PostingSchedule,
+		"queue":            queue,
+		"next_free_slots":  scheduler.NextFreeSlots(user, taskScheduler.Clock().Now()),
+	})
+}
+
+const (
+	defaultShareRunsLimit = 20
+	maxShareRunsLimit     = 100
+)
+
+// GetShareRunsHandler lists the user's share runs, newest first. It takes page and limit
+// for paging and blog_id, from and to (RFC3339) as filters.
+func GetShareRunsHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	params := req.URL.Query()
+	query := models.ShareRunQuery{BlogId: params.Get("blog_id"), Page: 1, Limit: defaultShareRunsLimit}
+	if value := params.Get("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			http.Error(resp, `{"error": "page must be a positive number"}`, http.StatusBadRequest)
+			return
+		}
+		query.Page = page
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxShareRunsLimit {
+			http.Error(resp, fmt.Sprintf(`{"error": "limit must be between 1 and %d"}`, maxShareRunsLimit), http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+	for name, bound := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := params.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(resp, fmt.Sprintf(`{"error": "%s must be an RFC3339 time"}`, name), http.StatusBadRequest)
+				return
+			}
+			*bound = parsed
+		}
+	}
+
+	runs, total, err := repo.GetShareRuns(userId, query)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get share runs for user id %s: %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{
+		"runs":  runs,
+		"page":  query.Page,
+		"limit": query.Limit,
+		"total": total,
+	})
+}
+
 func GetUserBlogsHandler(resp http.ResponseWriter, req *http.Request) {
 	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
 	if !ok {
@@ -591,11 +931,7 @@ func XcallbackHandler(resp http.ResponseWriter, req *http.Request) {
 	user.XOAuthToken = accessToken
 	user.XOAuthSecret = accessSecret
 	user.XVerified = true
-	if (user.XVerified || user.LinkedinVerified) && user.HashnodeVerified {
-		user.Verified = true
-	} else {
-		user.Verified = false
-	}
+	user.Verified = services.CanPublish(user)
 	err = repo.UpdateUser(userID, user)
 	if err != nil {
 		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
@@ -658,75 +994,594 @@ func LinkedCallbackHandler(resp http.ResponseWriter, req *http.Request) {
 		http.Error(resp, "Invalid state parameter", http.StatusForbidden)
 		return
 	}
-	cacheItem, exists := repo.GetCache(stateCookie.Value)
-	if !exists {
-		log.Printf("[ERROR] Invalid state parameter")
-		http.Error(resp, "Invalid state parameter", http.StatusForbidden)
+	cacheItem, exists := repo.GetCache(stateCookie.Value)
+	if !exists {
+		log.Printf("[ERROR] Invalid state parameter")
+		http.Error(resp, "Invalid state parameter", http.StatusForbidden)
+		return
+	}
+
+	userId, ok := cacheItem.(models.CacheItem)
+	if !ok {
+		log.Printf("[ERROR] Failed to cast cached item to CacheItem")
+		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	userIdStr, ok := userId.Value.(string)
+	if !ok {
+		log.Printf("[ERROR] Failed to cast CacheItem.Value to string")
+		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	err = repo.DeleteCache(stateCookie.Value)
+	if err != nil {
+		log.Printf("[WARN] Failed to delete state from cache for the user id: %s and error is %s", userId, err)
+	}
+
+	user, err := repo.GetUserById(userIdStr)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if user == nil {
+		log.Printf("[ERROR] User with id: %s not found", userId)
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+
+	code := req.URL.Query().Get("code")
+	if code == "" {
+		log.Printf("[ERROR] Missing authorization code")
+		http.Error(resp, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.Background()
+	token, err := linkedinConfig.Exchange(ctx, code)
+	if err != nil {
+		http.Error(resp, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user.LinkedInOauthKey = token.AccessToken
+	user.LinkedinVerified = true
+	user.Verified = services.CanPublish(user)
+	err = repo.UpdateUser(userIdStr, user)
+	if err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] User with ID %s connected to LinkedIn Successfully", userIdStr)
+
+	// Redirect the user back to the frontend
+	redirectUrl := fmt.Sprintf("%s/verification", frontendURL)
+	http.Redirect(resp, req, redirectUrl, http.StatusSeeOther)
+}
+
+// ConnectMastodonHandler starts the OAuth flow on the instance the user is on, our app
+// is registered on an instance the first time one of its users connects.
+func ConnectMastodonHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	instance, err := services.NormalizeMastodonInstance(req.URL.Query().Get("instance"))
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if mastodonCallbackURL == "" {
+		log.Printf("[ERROR] MASTODON_CALLBACK_URL is not set")
+		http.Error(resp, "Mastodon is not configured", http.StatusInternalServerError)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+
+	app, err := services.MastodonApp(instance, mastodonCallbackURL, frontendURL)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get a Mastodon app on %s: %v", instance, err)
+		http.Error(resp, "Failed to connect to the Mastodon instance", http.StatusBadGateway)
+		return
+	}
+
+	// the callback finishes the flow on this instance
+	user.MastodonInstance = instance
+	user.MastodonAccessToken = ""
+	user.MastodonVerified = false
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	state := uuid.New().String()
+	if err := repo.SetCache(state, userId, 10*time.Minute); err != nil {
+		log.Printf("[ERROR] Failed to store state in cache: %v", err)
+		http.Error(resp, "Failed to store state in cache", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(resp, &http.Cookie{
+		Name:     "mastodon_oauth_state",
+		Value:    state,
+		HttpOnly: true,
+		Path:     "/",
+		Secure:   false,
+		Expires:  time.Now().Add(10 * time.Minute),
+	})
+
+	authURL := services.MastodonOAuthConfig(app).AuthCodeURL(state)
+	http.Redirect(resp, req, authURL, http.StatusFound)
+}
+
+func MastodonCallbackHandler(resp http.ResponseWriter, req *http.Request) {
+	queryState := req.URL.Query().Get("state")
+	stateCookie, err := req.Cookie("mastodon_oauth_state")
+	if err != nil || stateCookie.Value != queryState {
+		log.Printf("[ERROR] Invalid state parameter")
+		http.Error(resp, "Invalid state parameter", http.StatusForbidden)
+		return
+	}
+	cacheItem, exists := repo.GetCache(stateCookie.Value)
+	if !exists {
+		log.Printf("[ERROR] Invalid state parameter")
+		http.Error(resp, "Invalid state parameter", http.StatusForbidden)
+		return
+	}
+	item, ok := cacheItem.(models.CacheItem)
+	if !ok {
+		log.Printf("[ERROR] Failed to cast cached item to CacheItem")
+		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	userId, ok := item.Value.(string)
+	if !ok {
+		log.Printf("[ERROR] Failed to cast CacheItem.Value to string")
+		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err := repo.DeleteCache(stateCookie.Value); err != nil {
+		log.Printf("[WARN] Failed to delete state from cache for the user id: %s and error is %s", userId, err)
+	}
+
+	user, err := repo.GetUserById(userId)
+	if err != nil || user == nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %v", userId, err)
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if user.MastodonInstance == "" {
+		http.Error(resp, "No Mastodon connection was started", http.StatusBadRequest)
+		return
+	}
+
+	code := req.URL.Query().Get("code")
+	if code == "" {
+		log.Printf("[ERROR] Missing authorization code")
+		http.Error(resp, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	app, err := repo.GetMastodonApp(user.MastodonInstance)
+	if err != nil || app == nil {
+		log.Printf("[ERROR] No Mastodon app on %s: %v", user.MastodonInstance, err)
+		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	token, err := services.ExchangeMastodonCode(app, code)
+	if err != nil {
+		http.Error(resp, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	user.MastodonAccessToken = token.AccessToken
+	user.MastodonVerified = true
+	user.Verified = services.CanPublish(user)
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] User with ID %s connected to Mastodon on %s Successfully", userId, user.MastodonInstance)
+
+	redirectUrl := fmt.Sprintf("%s/verification", frontendURL)
+	http.Redirect(resp, req, redirectUrl, http.StatusSeeOther)
+}
+
+// UpdateMastodonSettingsHandler sets the visibility and content warning of the user's
+// Mastodon statuses.
+func UpdateMastodonSettingsHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var settings models.MastodonSettings
+	if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.MastodonSettings = &settings
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// UpdateXThreadSettingsHandler turns thread mode for X on or off and sets which tweet of
+// a thread the blog link goes in.
+func UpdateXThreadSettingsHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var settings models.XThreadSettings
+	if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	user.XThread = &settings
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// ConnectBlueskyHandler connects the account of the handle, Bluesky takes an app
+// password instead of OAuth so it is checked by signing in with it.
+func ConnectBlueskyHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Handle      string `json:"handle"`
+		AppPassword string `json:"app_password"`
+		Service     string `json:"service"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(body.Handle) == "" || body.AppPassword == "" {
+		http.Error(resp, "Handle and app password are required", http.StatusBadRequest)
+		return
+	}
+	service, err := services.NormalizeBlueskyService(body.Service)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+
+	session, err := services.CreateBlueskySession(service, body.Handle, body.AppPassword)
+	if err != nil {
+		log.Printf("[ERROR] Failed to sign in to Bluesky for user id: %s and error is %v", userId, err)
+		http.Error(resp, "Failed to sign in to Bluesky, check the handle and app password", http.StatusUnauthorized)
+		return
+	}
+	user.BlueskyHandle = body.Handle
+	if session.Handle != "" {
+		user.BlueskyHandle = session.Handle
+	}
+	user.BlueskyAppPassword = body.AppPassword
+	user.BlueskyService = service
+	user.BlueskyVerified = true
+	user.Verified = services.CanPublish(user)
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] User with ID %s connected to Bluesky Successfully", userId)
+
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// ConnectTelegramHandler saves the bot and channel shares are sent to, once the bot
+// managed to post a test message to the channel.
+func ConnectTelegramHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		BotToken string `json:"bot_token"`
+		ChatId   string `json:"chat_id"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	botToken := strings.TrimSpace(body.BotToken)
+	chatId := strings.TrimSpace(body.ChatId)
+	if err := services.ValidateTelegramCredentials(botToken, chatId); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if err := services.TestTelegram(botToken, chatId); err != nil {
+		log.Printf("[ERROR] Telegram test failed for user id: %s and error is %v", userId, err)
+		http.Error(resp, "The bot couldn't post to the channel, make sure it is an admin of it", http.StatusBadGateway)
+		return
+	}
+	user.TelegramBotToken = botToken
+	user.TelegramChatId = chatId
+	user.TelegramVerified = true
+	user.Verified = services.CanPublish(user)
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] User with ID %s connected to Telegram Successfully", userId)
+
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// ConnectDevtoHandler saves the Dev.to API key blogs are cross posted with.
+func ConnectDevtoHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		ApiKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	apiKey := strings.TrimSpace(body.ApiKey)
+	if apiKey == "" {
+		http.Error(resp, "API key is required", http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	username, err := services.VerifyDevtoKey(apiKey)
+	if err != nil {
+		log.Printf("[ERROR] Failed to verify Dev.to key for user id: %s and error is %v", userId, err)
+		http.Error(resp, "Dev.to didn't accept the API key", http.StatusUnauthorized)
+		return
+	}
+	user.DevtoApiKey = apiKey
+	user.DevtoUsername = username
+	user.DevtoVerified = true
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[INFO] User with ID %s connected to Dev.to Successfully", userId)
+
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
+
+// CrossPostDevtoHandler republishes the full blog on Dev.to, or syncs the article when
+// the blog was cross posted before.
+func CrossPostDevtoHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Id        string `json:"id"`
+		Published *bool  `json:"published"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.Id == "" {
+		http.Error(resp, "missing blog id in the request", http.StatusBadRequest)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if !user.HashnodeVerified || !user.DevtoVerified {
+		http.Error(resp, "Connect Hashnode and Dev.to first", http.StatusForbidden)
+		return
+	}
+	// a cross post goes out live unless asked to stay a draft
+	published := body.Published == nil || *body.Published
+
+	crossPost, err := services.CrossPostToDevto(user, body.Id, published)
+	if err != nil {
+		log.Printf("[ERROR] Failed to cross post blog %s to Dev.to for user id %s: %v", body.Id, userId, err)
+		http.Error(resp, "Failed to cross post to Dev.to", http.StatusBadGateway)
+		return
+	}
+	log.Printf("[INFO] Blog with ID %s cross posted to Dev.to by user with ID %s", body.Id, userId)
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(crossPost)
+}
+
+// GetCrossPostsHandler lists the blogs the user cross posted.
+func GetCrossPostsHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	crossPosts, err := repo.GetCrossPosts(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get cross posts for user id %s: %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"cross_posts": crossPosts})
+}
 
-	userId, ok := cacheItem.(models.CacheItem)
+// UpdateWebhookHandler sets the Discord or Slack webhook shares are announced on, the
+// URL is only saved once a test message went through. An empty URL disconnects it.
+func UpdateWebhookHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
 	if !ok {
-		log.Printf("[ERROR] Failed to cast cached item to CacheItem")
-		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
 		return
 	}
-
-	userIdStr, ok := userId.Value.(string)
-	if !ok {
-		log.Printf("[ERROR] Failed to cast CacheItem.Value to string")
-		http.Error(resp, "Internal Server Error", http.StatusInternalServerError)
+	var body struct {
+		Url string `json:"url"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-
-	err = repo.DeleteCache(stateCookie.Value)
-	if err != nil {
-		log.Printf("[WARN] Failed to delete state from cache for the user id: %s and error is %s", userId, err)
+	webhookURL := strings.TrimSpace(body.Url)
+	if webhookURL != "" {
+		if _, err := services.WebhookKind(webhookURL); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
-
-	user, err := repo.GetUserById(userIdStr)
+	user, err := repo.GetUserById(userId)
 	if err != nil {
 		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
-		http.Error(resp, "User not found", http.StatusNotFound)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 	if user == nil {
-		log.Printf("[ERROR] User with id: %s not found", userId)
 		http.Error(resp, "User not found", http.StatusNotFound)
 		return
 	}
-
-	code := req.URL.Query().Get("code")
-	if code == "" {
-		log.Printf("[ERROR] Missing authorization code")
-		http.Error(resp, "Missing authorization code", http.StatusBadRequest)
+	if webhookURL != "" {
+		if err := services.TestWebhook(webhookURL); err != nil {
+			log.Printf("[ERROR] Webhook test failed for user id: %s and error is %v", userId, err)
+			http.Error(resp, "The webhook didn't accept the test message", http.StatusBadGateway)
+			return
+		}
+	}
+	user.WebHookUrl = webhookURL
+	user.Verified = services.CanPublish(user)
+	if err := repo.UpdateUser(userId, user); err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
 		return
 	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
+}
 
-	ctx := context.Background()
-	token, err := linkedinConfig.Exchange(ctx, code)
+// TestWebhookHandler sends a test message to the webhook the user saved.
+func TestWebhookHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	user, err := repo.GetUserById(userId)
 	if err != nil {
-		http.Error(resp, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	user.LinkedInOauthKey = token.AccessToken
-	user.LinkedinVerified = true
-	if (user.XVerified || user.LinkedinVerified) && user.HashnodeVerified {
-		user.Verified = true
-	} else {
-		user.Verified = false
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
 	}
-	err = repo.UpdateUser(userIdStr, user)
-	if err != nil {
-		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
-		http.Error(resp, "Failed to update user", http.StatusInternalServerError)
+	if user.WebHookUrl == "" {
+		http.Error(resp, "No webhook is set", http.StatusBadRequest)
 		return
 	}
-	log.Printf("[INFO] User with ID %s connected to LinkedIn Successfully", userIdStr)
-
-	// Redirect the user back to the frontend
-	redirectUrl := fmt.Sprintf("%s/verification", frontendURL)
-	http.Redirect(resp, req, redirectUrl, http.StatusSeeOther)
+	if err := services.TestWebhook(user.WebHookUrl); err != nil {
+		log.Printf("[ERROR] Webhook test failed for user id: %s and error is %v", userId, err)
+		http.Error(resp, "The webhook didn't accept the test message", http.StatusBadGateway)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(`{"success": true}`))
 }
 
 func ValidateLogin(req *http.Request) (string, error) {
@@ -846,11 +1701,7 @@ func VerifyHashnodeHandler(w http.ResponseWriter, r *http.Request) {
 	user.HashnodePAT = hashnodeKey.Key
 	user.HashnodeVerified = true
 	user.HashnodeBlog = url
-	if (user.XVerified || user.LinkedinVerified) && user.HashnodeVerified {
-		user.Verified = true
-	} else {
-		user.Verified = false
-	}
+	user.Verified = services.CanPublish(user)
 	err = repo.UpdateUser(userId, user)
 	if err != nil {
 		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
@@ -938,37 +1789,158 @@ func ScheduleBlogHandler(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 	blogData.UserID = userId
-	err = blogData.ScheduledBlog.Validate()
+	blogData.ScheduledBlog.TaskId = models.NewTaskId()
+	blogData.Status = ""
+	blogData.Attempts = 0
+	blogData.NextAttemptAt = time.Time{}
+	blogData.LastError = ""
+	blogData.ScheduledBlog.Status = ""
+	blogData.ScheduledBlog.LastError = ""
+	blogData.ScheduledBlog.Copy = nil
+	blogData.ScheduledBlog.Queued = false
+	blogData.ScheduledBlog.QueuedAt = time.Time{}
+	if blogData.ScheduledBlog.Recurrence != nil {
+		blogData.ScheduledBlog.Recurrence.Occurrences = 0
+	}
+	for i := range blogData.ScheduledBlog.Targets {
+		blogData.ScheduledBlog.Targets[i].Status = ""
+		blogData.ScheduledBlog.Targets[i].Result = ""
+		blogData.ScheduledBlog.Targets[i].SharedAt = time.Time{}
+	}
+	// a wall clock time is resolved in the zone sent with it, or in the user's
+	if err := blogData.ScheduledBlog.ResolveLocalTime(user.Timezone); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	blogData.ScheduledBlog.NormalizeTargets()
+	err = blogData.ScheduledBlog.Validate(taskScheduler.Clock())
 	if err != nil {
 		http.Error(resp, err.Error(), http.StatusBadRequest)
 		return
 	}
-	//check if the user has already scheduled the blog
-	for i := range user.ScheduledBlogs {
-		if user.ScheduledBlogs[i].Id == blogData.ScheduledBlog.Id {
-			http.Error(resp, "Blog already scheduled", http.StatusBadRequest)
+	err = taskScheduler.AddScheduledBlog(userId, blogData.ScheduledBlog)
+	if err != nil {
+		http.Error(resp, "Failed to store scheduled task", http.StatusInternalServerError)
+		return
+	}
+
+	user.ScheduledBlogs = append(user.ScheduledBlogs, blogData.ScheduledBlog)
+	err = repo.UpdateUser(userId, user)
+	if err != nil {
+		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("[INFO] Blog with ID %s scheduled successfully by user with ID %s as task %s", blogData.ScheduledBlog.Id, userId, blogData.ScheduledBlog.TaskId)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"success": true, "task_id": blogData.ScheduledBlog.TaskId})
+}
+
+// QueueBlogHandler appends a blog to the user's queue, each platform is shared in its
+// next free posting slot.
+func QueueBlogHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if !user.Verified {
+		http.Error(resp, "User is not verified", http.StatusForbidden)
+		return
+	}
+	var blogData models.ScheduledBlogData
+	if err := json.NewDecoder(req.Body).Decode(&blogData); err != nil {
+		http.Error(resp, "Failed to parse JSON", http.StatusBadRequest)
+		return
+	}
+	blog := models.ScheduledBlog{
+		Blog:      blogData.ScheduledBlog.Blog,
+		TaskId:    models.NewTaskId(),
+		Platforms: blogData.ScheduledBlog.Platforms,
+		CatchUp:   blogData.ScheduledBlog.CatchUp,
+	}
+	if err := blog.Blog.ValidateBase(); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(blog.Platforms) == 0 {
+		http.Error(resp, "at least one platform is required", http.StatusBadRequest)
+		return
+	}
+	if blog.CatchUp != nil {
+		if err := blog.CatchUp.Validate(); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
 			return
 		}
 	}
+	if err := blog.ResolveLocalTime(user.Timezone); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	err = taskScheduler.AddTask(blogData)
+	blog, err = taskScheduler.QueueBlog(userId, user, blog)
+	if errors.Is(err, scheduler.ErrNoPostingSlots) || errors.Is(err, scheduler.ErrQueueFull) {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
+		log.Printf("[ERROR] Failed to queue blog with id: %s and error is %s", blog.Id, err)
 		http.Error(resp, "Failed to store scheduled task", http.StatusInternalServerError)
 		return
 	}
 
-	user.ScheduledBlogs = append(user.ScheduledBlogs, blogData.ScheduledBlog)
-	err = repo.UpdateUser(userId, user)
-	if err != nil {
+	user.ScheduledBlogs = append(user.ScheduledBlogs, blog)
+	if err := repo.UpdateUser(userId, user); err != nil {
 		log.Printf("[ERROR] Failed to update user with id: %s and error is %s", userId, err)
 		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("[INFO] Blog with ID %s scheduled successfully by user with ID %s", blogData.ScheduledBlog.Id, userId)
+	log.Printf("[INFO] Blog with ID %s queued by user with ID %s as task %s", blog.Id, userId, blog.TaskId)
+	resp.Header().Set("Content-Type", "application/json")
 	resp.WriteHeader(http.StatusOK)
-	resp.Write([]byte(`{"success": true}`))
+	json.NewEncoder(resp).Encode(map[string]interface{}{"success": true, "task_id": blog.TaskId, "blog": blog})
+}
+
+// findUserSchedule returns the index of the schedule a request points at, by its task id
+// or, for clients from before task ids, by the blog id when the user scheduled that blog
+// only once. When there is no single match it returns -1 and the status to answer with.
+func findUserSchedule(user *models.User, taskId, blogId string) (int, int) {
+	if taskId != "" {
+		for i := range user.ScheduledBlogs {
+			if user.ScheduledBlogs[i].TaskKey() == taskId {
+				return i, http.StatusOK
+			}
+		}
+		return -1, http.StatusNotFound
+	}
 
+	found := -1
+	for i := range user.ScheduledBlogs {
+		if user.ScheduledBlogs[i].Id != blogId {
+			continue
+		}
+		if found >= 0 {
+			return -1, http.StatusConflict
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, http.StatusNotFound
+	}
+	return found, http.StatusOK
 }
 
 func CancelScheduledBlogHandler(resp http.ResponseWriter, req *http.Request) {
@@ -994,25 +1966,30 @@ func CancelScheduledBlogHandler(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 	var requestBody struct {
-		Id string `json:"id"`
+		TaskId string `json:"task_id"`
+		Id     string `json:"id"`
 	}
 	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
 		http.Error(resp, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	blogId := requestBody.Id
-	if len(blogId) == 0 {
-		http.Error(resp, "Missing blog id", http.StatusBadRequest)
+	if requestBody.TaskId == "" && requestBody.Id == "" {
+		http.Error(resp, "Missing task id", http.StatusBadRequest)
 		return
 	}
-	var updatedScheduledBlogs []models.ScheduledBlog
-	for _, blog := range user.ScheduledBlogs {
-		if blog.Id == blogId {
-			continue
-		}
-		updatedScheduledBlogs = append(updatedScheduledBlogs, blog)
+	// a schedule the user doesn't have anymore may still have stored tasks, they are
+	// cleaned up all the same
+	cancelled := models.ScheduledBlog{Blog: models.Blog{Id: requestBody.Id}, TaskId: requestBody.TaskId}
+	index, status := findUserSchedule(user, requestBody.TaskId, requestBody.Id)
+	if status == http.StatusConflict {
+		http.Error(resp, "Blog is scheduled more than once, cancel it by its task id", http.StatusConflict)
+		return
+	}
+	if index >= 0 {
+		cancelled = user.ScheduledBlogs[index]
+		user.ScheduledBlogs = append(user.ScheduledBlogs[:index], user.ScheduledBlogs[index+1:]...)
 	}
-	user.ScheduledBlogs = updatedScheduledBlogs
+	taskKey := cancelled.TaskKey()
 	err = repo.UpdateUser(userId, user)
 	if err != nil {
 
@@ -1020,17 +1997,197 @@ func CancelScheduledBlogHandler(resp http.ResponseWriter, req *http.Request) {
 		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	err = taskScheduler.RemoveTask(blogId)
+	err = taskScheduler.RemoveTask(cancelled)
+	if err != nil {
+		log.Printf("[ERROR] Failed to remove scheduled task with id: %s and error is %s", taskKey, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// failed targets are no longer in the scheduler, so their stored tasks are dropped here
+	err = repo.DeleteScheduledBlogTasks(userId, cancelled)
 	if err != nil {
-		log.Printf("[ERROR] Failed to remove scheduled task with id: %s and error is %s", blogId, err)
+		log.Printf("[ERROR] Failed to delete stored tasks with id: %s and error is %s", taskKey, err)
 		http.Error(resp, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	log.Printf("[INFO] Scheduled blog with ID %s cancelled successfully by user with ID %s", blogId, userId)
+	// the blogs queued behind a cancelled one move up a slot
+	if cancelled.Queued {
+		if err := taskScheduler.RepackQueue(userId, user); err != nil {
+			log.Printf("[ERROR] Failed to repack the queue of user with id: %s and error is %s", userId, err)
+			http.Error(resp, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	log.Printf("[INFO] Scheduled task %s of blog with ID %s cancelled successfully by user with ID %s", taskKey, cancelled.Id, userId)
 	resp.WriteHeader(http.StatusOK)
 	resp.Write([]byte(`{"success": true}`))
 }
 
+// RescheduleBlogHandler changes the time and/or platforms of a scheduled blog in one
+// request, targets that were already shared or gave up are left as they are.
+func RescheduleBlogHandler(resp http.ResponseWriter, req *http.Request) {
+	userId, ok := req.Context().Value(middlewares.UserIDKey).(string)
+	if !ok {
+		http.Error(resp, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+	user, err := repo.GetUserById(userId)
+	if err != nil {
+		log.Printf("[ERROR] Failed to get user for the id: %s and error is %s", userId, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		log.Printf("[ERROR] User with id: %s not found", userId)
+		http.Error(resp, "User not found", http.StatusNotFound)
+		return
+	}
+	if !user.Verified {
+		log.Printf("[ERROR] User with id: %s is not verified", userId)
+		http.Error(resp, "User is not verified", http.StatusForbidden)
+		return
+	}
+	var requestBody struct {
+		TaskId        string                  `json:"task_id"`
+		Id            string                  `json:"id"`
+		ScheduledTime *time.Time              `json:"scheduled_time"`
+		LocalTime     string                  `json:"local_time"`
+		Timezone      string                  `json:"timezone"`
+		Platforms     []string                `json:"platforms"`
+		Targets       []models.PlatformTarget `json:"targets"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		http.Error(resp, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.TaskId == "" && requestBody.Id == "" {
+		http.Error(resp, "Missing task id", http.StatusBadRequest)
+		return
+	}
+	if requestBody.ScheduledTime == nil && requestBody.LocalTime == "" && requestBody.Platforms == nil && requestBody.Targets == nil {
+		http.Error(resp, "Nothing to reschedule", http.StatusBadRequest)
+		return
+	}
+
+	index, status := findUserSchedule(user, requestBody.TaskId, requestBody.Id)
+	if status == http.StatusConflict {
+		http.Error(resp, "Blog is scheduled more than once, reschedule it by its task id", http.StatusConflict)
+		return
+	}
+	if index < 0 {
+		http.Error(resp, "Scheduled blog not found", http.StatusNotFound)
+		return
+	}
+	current := &user.ScheduledBlogs[index]
+	taskKey := current.TaskKey()
+	timezone := current.Timezone
+	if requestBody.LocalTime != "" {
+		zone := requestBody.Timezone
+		if zone == "" {
+			zone = current.Timezone
+		}
+		wallClock := models.ScheduledBlog{LocalTime: requestBody.LocalTime, Timezone: zone}
+		if err := wallClock.ResolveLocalTime(user.Timezone); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requestBody.ScheduledTime = &wallClock.ScheduledTime
+		timezone = wallClock.Timezone
+	}
+	if current.Status == models.TaskStatusFailed {
+		http.Error(resp, "Scheduled blog has failed, schedule it again instead", http.StatusConflict)
+		return
+	}
+	for _, target := range current.Targets {
+		if target.Status == models.TaskStatusHeld {
+			http.Error(resp, "Scheduled blog is held for review, release or cancel it first", http.StatusConflict)
+			return
+		}
+	}
+	if current.Status == models.TaskStatusHeld {
+		http.Error(resp, "Scheduled blog is held for review, release or cancel it first", http.StatusConflict)
+		return
+	}
+
+	// blogs scheduled before targets existed only have the platforms
+	existing := current.Targets
+	if len(existing) == 0 {
+		legacy := *current
+		legacy.NormalizeTargets()
+		existing = legacy.Targets
+	}
+
+	platforms := requestBody.Platforms
+	requested := map[string]time.Time{}
+	for _, target := range requestBody.Targets {
+		requested[target.Platform] = target.ScheduledTime
+		if requestBody.Platforms == nil {
+			platforms = append(platforms, target.Platform)
+		}
+	}
+	if platforms == nil {
+		platforms = current.Platforms
+	}
+
+	updated := *current
+	updated.Timezone = timezone
+	updated.Targets = nil
+	for _, platform := range platforms {
+		target := models.PlatformTarget{Platform: platform, ScheduledTime: current.ScheduledTime}
+		for _, old := range existing {
+			if old.Platform == platform {
+				target = old
+				break
+			}
+		}
+		if !target.Settled() {
+			if at, ok := requested[platform]; ok {
+				target.ScheduledTime = at
+			} else if requestBody.ScheduledTime != nil {
+				target.ScheduledTime = *requestBody.ScheduledTime
+			}
+			target.Result = ""
+		}
+		updated.Targets = append(updated.Targets, target)
+	}
+	// picking a time takes a queued blog out of the queue, new platforms of a queued
+	// blog get a slot when the queue is repacked below
+	if requestBody.ScheduledTime != nil || requestBody.Targets != nil {
+		updated.Queued = false
+		updated.QueuedAt = time.Time{}
+	}
+	updated.NormalizeTargets()
+	if err := updated.Validate(taskScheduler.Clock()); err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wasQueued := current.Queued
+	err = taskScheduler.RescheduleBlog(userId, *current, updated)
+	if errors.Is(err, scheduler.ErrTargetRunning) {
+		http.Error(resp, "Blog is being shared right now, try again in a moment", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to reschedule task with id: %s and error is %s", taskKey, err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if wasQueued {
+		user.ScheduledBlogs[index] = updated
+		if err := taskScheduler.RepackQueue(userId, user); err != nil {
+			log.Printf("[ERROR] Failed to repack the queue of user with id: %s and error is %s", userId, err)
+		}
+		updated = user.ScheduledBlogs[index]
+	}
+
+	log.Printf("[INFO] Scheduled task %s rescheduled successfully by user with ID %s", taskKey, userId)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(map[string]interface{}{"success": true, "blog": updated})
+}
+
 func VerifyEmailHandler(resp http.ResponseWriter, req *http.Request) {
 	if req.Body == nil {
 		resp.Header().Set("Content-Type", "application/json")
@@ -1085,11 +2242,7 @@ func VerifyEmailHandler(resp http.ResponseWriter, req *http.Request) {
 	}
 
 	user.EmailVerified = true
-	if (user.XVerified || user.LinkedinVerified) && user.HashnodeVerified && user.EmailVerified {
-		user.Verified = true
-	} else {
-		user.Verified = false
-	}
+	user.Verified = services.CanPublish(user) && user.EmailVerified
 	if err := repo.UpdateUser(userId, user); err != nil {
 		log.Printf("[ERROR] Failed to update user with id %s: %v", userId, err)
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -1141,18 +2294,7 @@ func ResetEmailOtpHandler(resp http.ResponseWriter, req *http.Request) {
 
 	message := fmt.Sprintf("Your OTP is: %s\n OTP will expire in 30 minutes", otp)
 
-	// this is just a temporary work around to use the existing heap based queue system
-	// to send emails asynchronously and we need a better way to do this in the future
-
-	emailTask := models.ScheduledBlogData{}
-	shareTime := models.ScheduledBlog{}
-	shareTime.ScheduledTime = time.Now()
-	emailTask.EmailId = user.UserName
-	emailTask.Message = message
-	emailTask.ScheduledBlog = shareTime
-	emailTask.UserID = userId
-
-	err = taskScheduler.AddTask(emailTask)
+	err = taskScheduler.AddJob(models.NewEmailJob(userId, user.UserName, message, taskScheduler.Clock().Now()))
 	if err != nil {
 		log.Printf("[ERROR] Failed adding email task to the queue, reason: %s", err)
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -1206,18 +2348,7 @@ func ForgotPasswordHandler(resp http.ResponseWriter, req *http.Request) {
 
 	message := fmt.Sprintf("Your OTP for password reset is: %s\n(Expires in 10 minutes)", otp)
 
-	// this is just a temporary work around to use the existing heap based queue system
-	// to send emails asynchronously and we need a better way to do this in the future
-
-	emailTask := models.ScheduledBlogData{}
-	shareTime := models.ScheduledBlog{}
-	shareTime.ScheduledTime = time.Now()
-	emailTask.EmailId = user.UserName
-	emailTask.Message = message
-	emailTask.ScheduledBlog = shareTime
-	emailTask.UserID = userId
-
-	err = taskScheduler.AddTask(emailTask)
+	err = taskScheduler.AddJob(models.NewEmailJob(userId, user.UserName, message, taskScheduler.Clock().Now()))
 	if err != nil {
 		log.Printf("[ERROR] Failed adding email task to the queue, reason: %s", err)
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -1350,8 +2481,11 @@ func DeleteAccountHandler(resp http.ResponseWriter, req *http.Request) {
 
 	// Delete any scheduled tasks before cache cleanup
 	for _, blog := range user.ScheduledBlogs {
-		if err := taskScheduler.RemoveTask(blog.Id); err != nil {
-			log.Printf("[ERROR] Failed to remove scheduled task %s: %s", blog.Id, err)
+		if err := taskScheduler.RemoveTask(blog); err != nil {
+			log.Printf("[ERROR] Failed to remove scheduled task %s: %s", blog.TaskKey(), err)
+		}
+		if err := repo.DeleteScheduledBlogTasks(userId, blog); err != nil {
+			log.Printf("[ERROR] Failed to delete stored tasks of schedule %s: %s", blog.TaskKey(), err)
 		}
 	}
 
@@ -1409,3 +2543,16 @@ func DeleteAccountHandler(resp http.ResponseWriter, req *http.Request) {
 	resp.WriteHeader(http.StatusOK)
 	resp.Write([]byte(`{"success": true}`))
 }
+
+// SchedulerStatsHandler reports the state of the scheduler to operators.
+func SchedulerStatsHandler(resp http.ResponseWriter, req *http.Request) {
+	stats, err := taskScheduler.Stats()
+	if err != nil {
+		log.Printf("[ERROR] Failed to read the scheduler queue: %s", err)
+		http.Error(resp, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	json.NewEncoder(resp).Encode(stats)
+}
@@ -46,14 +46,17 @@ func init() {
 	services.SendEmail = func(toEmail, message string) error {
 		return nil
 	}
-	repositories.GetScheduledTasks = func() ([]models.ScheduledBlogData, error) {
-		return []models.ScheduledBlogData{}, nil
+	repositories.GetScheduledJobs = func() ([]models.Job, error) {
+		return []models.Job{}, nil
 	}
-	repositories.StoreScheduledTask = func(task models.ScheduledBlogData) error {
+	repositories.StoreScheduledJob = func(job models.Job) error {
 		return nil
 	}
 
-	repositories.DeleteScheduledTask = func(task models.ScheduledBlogData) error {
+	repositories.DeleteScheduledJob = func(job models.Job) error {
+		return nil
+	}
+	repositories.InsertShareRun = func(run *models.ShareRun) error {
 		return nil
 	}
 
@@ -227,3 +230,254 @@ func TestGetUserInfoHandler_UserNotFound(t *testing.T) {
 	handlers.GetUserInfoHandler(respRecorder, req)
 	assert.Equal(t, http.StatusNotFound, respRecorder.Code)
 }
+
+// --- Test RescheduleBlogHandler ---
+
+func TestRescheduleBlogHandler_Success(t *testing.T) {
+	userId := primitive.NewObjectID().Hex()
+	user := &models.User{Verified: true}
+	repositories.GetUserById = func(id string) (*models.User, error) {
+		return user, nil
+	}
+	repositories.UpdateUser = func(id string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	var saved models.ScheduledBlog
+	repositories.SaveRescheduledBlog = func(id string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, deleted []models.Job) error {
+		saved = blog
+		return nil
+	}
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	scheduleBody := `{"blog": {"id": "reschedule-blog", "title": "A blog", "url": "https://blog.example.com/a-blog",
+		"coverImage": {"url": "https://cdn.example.com/cover.png"}, "author": {"name": "Author"},
+		"platforms": ["twitter"], "scheduled_time": "` + at.Format(time.RFC3339) + `"}}`
+	req := httptest.NewRequest("POST", "/api/v1/blogs/schedule", strings.NewReader(scheduleBody))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder := httptest.NewRecorder()
+	handlers.ScheduleBlogHandler(respRecorder, req)
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+
+	later := at.Add(2 * time.Hour)
+	rescheduleBody := `{"id": "reschedule-blog", "scheduled_time": "` + later.Format(time.RFC3339) + `"}`
+	req = httptest.NewRequest("PATCH", "/api/v1/user/scheduled-blogs/reschedule", strings.NewReader(rescheduleBody))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder = httptest.NewRecorder()
+	handlers.RescheduleBlogHandler(respRecorder, req)
+
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Equal(t, later, saved.ScheduledTime)
+	assert.Len(t, saved.Targets, 1)
+	assert.Equal(t, later, saved.Targets[0].ScheduledTime)
+}
+
+func TestRescheduleBlogHandler_NotFound(t *testing.T) {
+	repositories.GetUserById = func(id string) (*models.User, error) {
+		return &models.User{Verified: true}, nil
+	}
+	req := httptest.NewRequest("PATCH", "/api/v1/user/scheduled-blogs/reschedule", strings.NewReader(`{"id": "missing", "platforms": ["linkedin"]}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.RescheduleBlogHandler(respRecorder, req)
+	assert.Equal(t, http.StatusNotFound, respRecorder.Code)
+}
+
+func TestGetShareRunsHandler_Paginates(t *testing.T) {
+	userId := primitive.NewObjectID().Hex()
+	var got models.ShareRunQuery
+	repositories.GetShareRuns = func(id string, query models.ShareRunQuery) ([]models.ShareRun, int64, error) {
+		assert.Equal(t, userId, id)
+		got = query
+		return []models.ShareRun{{UserID: id, BlogId: "blog1", Trigger: models.ShareTriggerScheduled}}, 11, nil
+	}
+	req := httptest.NewRequest("GET", "/api/v1/user/share-runs?page=2&limit=10&blog_id=blog1&from=2025-01-01T00:00:00Z", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.GetShareRunsHandler(respRecorder, req)
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Equal(t, 2, got.Page)
+	assert.Equal(t, 10, got.Limit)
+	assert.Equal(t, "blog1", got.BlogId)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), got.From)
+	assert.True(t, got.To.IsZero())
+
+	var body struct {
+		Runs  []models.ShareRun `json:"runs"`
+		Total int64             `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(respRecorder.Body.Bytes(), &body))
+	assert.Len(t, body.Runs, 1)
+	assert.Equal(t, int64(11), body.Total)
+}
+
+func TestGetShareRunsHandler_InvalidLimit(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/user/share-runs?limit=500", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.GetShareRunsHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+// --- Test scheduling a blog more than once ---
+
+func TestScheduleBlogHandler_SameBlogTwice(t *testing.T) {
+	userId := primitive.NewObjectID().Hex()
+	user := &models.User{Verified: true}
+	repositories.GetUserById = func(id string) (*models.User, error) {
+		return user, nil
+	}
+	repositories.UpdateUser = func(id string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	repositories.DeleteScheduledBlogTasks = func(id string, blog models.ScheduledBlog) error {
+		return nil
+	}
+
+	var taskIds []string
+	for _, at := range []time.Time{time.Now().Add(time.Hour), time.Now().Add(48 * time.Hour)} {
+		scheduleBody := `{"blog": {"id": "twice-blog", "title": "A blog", "url": "https://blog.example.com/a-blog",
+			"coverImage": {"url": "https://cdn.example.com/cover.png"}, "author": {"name": "Author"},
+			"platforms": ["twitter"], "scheduled_time": "` + at.UTC().Format(time.RFC3339) + `"}}`
+		req := httptest.NewRequest("POST", "/api/v1/blogs/schedule", strings.NewReader(scheduleBody))
+		req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+		respRecorder := httptest.NewRecorder()
+		handlers.ScheduleBlogHandler(respRecorder, req)
+		assert.Equal(t, http.StatusOK, respRecorder.Code)
+
+		var body struct {
+			TaskId string `json:"task_id"`
+		}
+		assert.NoError(t, json.Unmarshal(respRecorder.Body.Bytes(), &body))
+		assert.NotEmpty(t, body.TaskId)
+		taskIds = append(taskIds, body.TaskId)
+	}
+	assert.NotEqual(t, taskIds[0], taskIds[1])
+	assert.Len(t, user.ScheduledBlogs, 2)
+
+	// the blog id alone doesn't tell the two schedules apart
+	req := httptest.NewRequest("DELETE", "/api/v1/user/scheduled-blogs/cancel", strings.NewReader(`{"id": "twice-blog"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder := httptest.NewRecorder()
+	handlers.CancelScheduledBlogHandler(respRecorder, req)
+	assert.Equal(t, http.StatusConflict, respRecorder.Code)
+
+	req = httptest.NewRequest("DELETE", "/api/v1/user/scheduled-blogs/cancel", strings.NewReader(`{"task_id": "`+taskIds[0]+`"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder = httptest.NewRecorder()
+	handlers.CancelScheduledBlogHandler(respRecorder, req)
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Len(t, user.ScheduledBlogs, 1)
+	assert.Equal(t, taskIds[1], user.ScheduledBlogs[0].TaskId)
+}
+
+// --- Test timezones ---
+
+func TestScheduleBlogHandler_LocalTime(t *testing.T) {
+	userId := primitive.NewObjectID().Hex()
+	user := &models.User{Verified: true, Timezone: "Asia/Kolkata"}
+	repositories.GetUserById = func(id string) (*models.User, error) {
+		return user, nil
+	}
+	repositories.UpdateUser = func(id string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+
+	kolkata, _ := time.LoadLocation("Asia/Kolkata")
+	at := time.Now().In(kolkata).Add(3 * time.Hour).Truncate(time.Minute)
+	scheduleBody := `{"blog": {"id": "local-blog", "title": "A blog", "url": "https://blog.example.com/a-blog",
+		"coverImage": {"url": "https://cdn.example.com/cover.png"}, "author": {"name": "Author"},
+		"platforms": ["twitter"], "local_time": "` + at.Format("2006-01-02 15:04") + `"}}`
+	req := httptest.NewRequest("POST", "/api/v1/blogs/schedule", strings.NewReader(scheduleBody))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, userId))
+	respRecorder := httptest.NewRecorder()
+	handlers.ScheduleBlogHandler(respRecorder, req)
+
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Len(t, user.ScheduledBlogs, 1)
+	assert.Equal(t, "Asia/Kolkata", user.ScheduledBlogs[0].Timezone)
+	assert.True(t, at.Equal(user.ScheduledBlogs[0].ScheduledTime))
+}
+
+func TestUpdateTimezoneHandler_Invalid(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/api/v1/user/timezone", strings.NewReader(`{"timezone": "Europe/Atlantis"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.UpdateTimezoneHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+func TestUpdateMastodonSettingsHandler(t *testing.T) {
+	user := &models.User{}
+	repositories.GetUserById = func(id string) (*models.User, error) {
+		return user, nil
+	}
+	repositories.UpdateUser = func(id string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+
+	req := httptest.NewRequest("PUT", "/api/v1/user/mastodon-settings", strings.NewReader(`{"visibility": "everyone"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+	handlers.UpdateMastodonSettingsHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+
+	req = httptest.NewRequest("PUT", "/api/v1/user/mastodon-settings", strings.NewReader(`{"visibility": "unlisted", "spoiler_text": "new blog post"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder = httptest.NewRecorder()
+	handlers.UpdateMastodonSettingsHandler(respRecorder, req)
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Equal(t, &models.MastodonSettings{Visibility: models.MastodonUnlisted, SpoilerText: "new blog post"}, user.MastodonSettings)
+}
+
+func TestConnectMastodonHandler_InvalidInstance(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/user/connect-mastodon?instance=localhost", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.ConnectMastodonHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+func TestConnectBlueskyHandler_MissingPassword(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/user/connect-bluesky", strings.NewReader(`{"handle": "me.bsky.social"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.ConnectBlueskyHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+func TestUpdateWebhookHandler_RejectsOtherHosts(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/api/v1/user/webhook", strings.NewReader(`{"url": "https://example.com/hook"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.UpdateWebhookHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+func TestConnectTelegramHandler_InvalidToken(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/user/connect-telegram", strings.NewReader(`{"bot_token": "not a token", "chat_id": "@blog"}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.ConnectTelegramHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
+
+func TestConnectDevtoHandler_MissingKey(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/user/connect-devto", strings.NewReader(`{"api_key": " "}`))
+	req = req.WithContext(context.WithValue(req.Context(), middlewares.UserIDKey, primitive.NewObjectID().Hex()))
+	respRecorder := httptest.NewRecorder()
+
+	handlers.ConnectDevtoHandler(respRecorder, req)
+	assert.Equal(t, http.StatusBadRequest, respRecorder.Code)
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AdminMiddleware lets through requests that carry the ADMIN_TOKEN as a bearer token,
+// admin routes are disabled when no token is configured.
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		if adminToken == "" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success": false, "reason": "Not found"}`))
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"success": false, "reason": "Unauthorized: Invalid admin token"}`))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
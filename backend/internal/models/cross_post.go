@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const CrossPostDevto = "devto"
+
+// CrossPost is a blog republished in full on another platform, it keeps the id of the
+// remote article so later edits of the blog update it instead of posting a copy.
+type CrossPost struct {
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserId    string             `json:"user_id" bson:"user_id"`
+	BlogId    string             `json:"blog_id" bson:"blog_id"`
+	Platform  string             `json:"platform" bson:"platform"`
+	RemoteId  string             `json:"remote_id" bson:"remote_id"`
+	RemoteUrl string             `json:"remote_url" bson:"remote_url"`
+	Published bool               `json:"published" bson:"published"`
+	// ContentHash is the sha256 of the article as last sent, a sync of an unchanged
+	// blog sends nothing
+	ContentHash string    `json:"content_hash" bson:"content_hash"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
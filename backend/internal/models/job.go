@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	JobKindShareBlog = "share_blog"
+	JobKindSendEmail = "send_email"
+)
+
+// Job is a unit of background work run by the scheduler. Every kind carries its own
+// payload type, the scheduler only needs to know when the job is due and how to find
+// it again in the queue.
+type Job interface {
+	// Kind picks the handler that runs the job.
+	Kind() string
+	// Key identifies the job in the queue, pushing a job with the same key replaces it.
+	Key() string
+	// RunAt is the time the job is due.
+	RunAt() time.Time
+	// Owner is the id of the user the job belongs to.
+	Owner() string
+}
+
+// DecodeJob decodes a stored job of the given kind, decode fills in the value it is
+// given the way json.Unmarshal or bson.Unmarshal would. Jobs stored before jobs had a
+// kind are all blog shares.
+func DecodeJob(kind string, decode func(v interface{}) error) (Job, error) {
+	switch kind {
+	case JobKindShareBlog, "":
+		var job ScheduledBlogData
+		if err := decode(&job); err != nil {
+			return nil, err
+		}
+		return job, nil
+	case JobKindSendEmail:
+		var job EmailJob
+		if err := decode(&job); err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	return nil, fmt.Errorf("unknown job kind %q", kind)
+}
+
+func (t ScheduledBlogData) Kind() string { return JobKindShareBlog }
+
+// Key of a blog share is the task id of its schedule, and the platform for a single
+// target, so the share can be cancelled with it.
+func (t ScheduledBlogData) Key() string {
+	return ShareJobKey(t.ScheduledBlog.TaskKey(), t.Platform)
+}
+
+func (t ScheduledBlogData) Owner() string { return t.UserID }
+
+// ShareJobKey is the queue key of the share of a schedule to a platform, an empty
+// platform is the key of a share from before targets existed.
+func ShareJobKey(taskKey, platform string) string {
+	if platform == "" {
+		return taskKey
+	}
+	return taskKey + ":" + platform
+}
+
+// EmailJob sends an email in the background, like OTPs and password resets.
+type EmailJob struct {
+	Id      string    `json:"id" bson:"id"`
+	UserID  string    `json:"user_id" bson:"user_id"`
+	To      string    `json:"to" bson:"to"`
+	Message string    `json:"message" bson:"message"`
+	SendAt  time.Time `json:"send_at" bson:"send_at"`
+}
+
+// NewEmailJob creates a job that sends the message to the given address at sendAt,
+// the scheduler's clock tells when now is.
+func NewEmailJob(userId, to, message string, sendAt time.Time) EmailJob {
+	return EmailJob{
+		Id:      primitive.NewObjectID().Hex(),
+		UserID:  userId,
+		To:      to,
+		Message: message,
+		SendAt:  sendAt,
+	}
+}
+
+func (e EmailJob) Kind() string     { return JobKindSendEmail }
+func (e EmailJob) Key() string      { return "email:" + e.Id }
+func (e EmailJob) RunAt() time.Time { return e.SendAt }
+func (e EmailJob) Owner() string    { return e.UserID }
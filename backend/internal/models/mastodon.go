@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	MastodonPublic   = "public"
+	MastodonUnlisted = "unlisted"
+	MastodonPrivate  = "private"
+	MastodonDirect   = "direct"
+
+	// MastodonMaxChars is the default status limit, the content warning counts towards it
+	MastodonMaxChars = 500
+)
+
+// MastodonApp is the OAuth app we registered on a Mastodon instance, every instance
+// hands out its own client.
+type MastodonApp struct {
+	Instance     string `json:"instance" bson:"instance"`
+	ClientId     string `json:"client_id" bson:"client_id"`
+	ClientSecret string `json:"client_secret" bson:"client_secret"`
+	RedirectURI  string `json:"redirect_uri" bson:"redirect_uri"`
+}
+
+// MastodonSettings are how the user's statuses are posted, empty visibility is public.
+type MastodonSettings struct {
+	Visibility string `json:"visibility" bson:"visibility"`
+	// SpoilerText is the content warning shown in place of the status until expanded
+	SpoilerText string `json:"spoiler_text" bson:"spoiler_text,omitempty"`
+	Sensitive   bool   `json:"sensitive" bson:"sensitive"`
+}
+
+func (m *MastodonSettings) Validate() error {
+	switch m.Visibility {
+	case "", MastodonPublic, MastodonUnlisted, MastodonPrivate, MastodonDirect:
+	default:
+		return fmt.Errorf("invalid visibility: %s, expected one of public, unlisted, private or direct", m.Visibility)
+	}
+	// leave room for the status itself
+	if utf8.RuneCountInString(m.SpoilerText) > 100 {
+		return fmt.Errorf("spoiler_text must be 100 characters or less")
+	}
+	return nil
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"social-scribe/backend/internal/clock"
 )
 
 type User struct {
@@ -18,6 +20,7 @@ type User struct {
 	HashnodeVerified bool               `json:"hashnode_verified" bson:"hashnode_verified"`
 	LinkedinVerified bool               `json:"linkedin_verified" bson:"linkedin_verified"`
 	XVerified        bool               `json:"x_verified" bson:"x_verified"`
+	XThread          *XThreadSettings   `json:"x_thread,omitempty" bson:"x_thread,omitempty"`
 	WebHookUrl       string             `json:"webhook_url" bson:"webhook_url"`
 	HashnodeBlog     string             `json:"hashnode_blog" bson:"hashnode_blog"`
 	XOAuthToken      string             `json:"x_oauth_token" bson:"x_oauth_token"`
@@ -27,6 +30,34 @@ type User struct {
 	SharedBlogs      []SharedBlog       `json:"shared_posts" bson:"shared_posts"`
 	ScheduledBlogs   []ScheduledBlog    `json:"scheduled_posts" bson:"scheduled_posts"`
 	Notifications    []string           `json:"notifications" bson:"notifications"`
+	// CatchUpPolicy applies to the user's shares that are due while the backend is
+	// down, scheduled blogs can override it
+	CatchUpPolicy *CatchUpPolicy `json:"catch_up_policy,omitempty" bson:"catch_up_policy,omitempty"`
+	// PostingSchedule holds the weekly slots queued blogs are shared in
+	PostingSchedule *PostingSchedule `json:"posting_schedule,omitempty" bson:"posting_schedule,omitempty"`
+	// Timezone is the IANA name of the zone wall clock times of the user are in, empty is UTC
+	Timezone string `json:"timezone" bson:"timezone,omitempty"`
+	// PublishingRules hold back scheduled shares during quiet hours and blackout dates
+	PublishingRules *PublishingRules `json:"publishing_rules,omitempty" bson:"publishing_rules,omitempty"`
+	// MastodonInstance is the host of the user's Mastodon server, it is set when the
+	// connect flow starts so the callback knows which server to finish it with
+	MastodonInstance    string            `json:"mastodon_instance" bson:"mastodon_instance,omitempty"`
+	MastodonAccessToken string            `json:"mastodon_access_token" bson:"mastodon_access_token,omitempty"`
+	MastodonVerified    bool              `json:"mastodon_verified" bson:"mastodon_verified"`
+	MastodonSettings    *MastodonSettings `json:"mastodon_settings,omitempty" bson:"mastodon_settings,omitempty"`
+	// Bluesky signs in with an app password, BlueskyService is the PDS of the account
+	BlueskyHandle      string `json:"bluesky_handle" bson:"bluesky_handle,omitempty"`
+	BlueskyAppPassword string `json:"bluesky_app_password" bson:"bluesky_app_password,omitempty"`
+	BlueskyService     string `json:"bluesky_service" bson:"bluesky_service,omitempty"`
+	BlueskyVerified    bool   `json:"bluesky_verified" bson:"bluesky_verified"`
+	// TelegramChatId is the @username or numeric id of the channel the user's bot posts to
+	TelegramBotToken string `json:"telegram_bot_token" bson:"telegram_bot_token,omitempty"`
+	TelegramChatId   string `json:"telegram_chat_id" bson:"telegram_chat_id,omitempty"`
+	TelegramVerified bool   `json:"telegram_verified" bson:"telegram_verified"`
+	// Dev.to takes the full article rather than a teaser, see CrossPost
+	DevtoApiKey   string `json:"devto_api_key" bson:"devto_api_key,omitempty"`
+	DevtoUsername string `json:"devto_username" bson:"devto_username,omitempty"`
+	DevtoVerified bool   `json:"devto_verified" bson:"devto_verified"`
 }
 
 type Session struct {
@@ -42,13 +73,33 @@ type LoginStruct struct {
 	Password string `json:"password" bson:"password"`
 }
 
+const (
+	TaskStatusPending = "pending"
+	TaskStatusShared  = "shared"
+	TaskStatusFailed  = "failed"
+	// TaskStatusSkipped is a target that missed its time and was skipped by the catch up policy
+	TaskStatusSkipped = "skipped"
+	// TaskStatusHeld is a target that fell in quiet hours or a blackout and waits for the
+	// user to release it
+	TaskStatusHeld = "held"
+)
+
 type ScheduledBlogData struct {
 	UserID        string        `json:"user_id" bson:"user_id"`
 	ScheduledBlog ScheduledBlog `json:"blog" bson:"blog"`
-	// the below two fields shouldnt here but in a seperate struct but for now
-	//  iam adding here.
-	EmailId string `json:"email_id" bson:"email_id"`
-	Message string `json:"message" bson:"message"`
+	// Platform is set when the task shares a single target of a scheduled blog, older
+	// tasks leave it empty and share to every platform in ScheduledBlog.Platforms
+	Platform string `json:"platform,omitempty" bson:"platform,omitempty"`
+	// retry bookkeeping, a failed share is re-enqueued at NextAttemptAt until
+	// Attempts reaches MaxAttempts and the task is marked as failed
+	Status        string    `json:"status" bson:"status"`
+	Attempts      int       `json:"attempts" bson:"attempts"`
+	MaxAttempts   int       `json:"max_attempts" bson:"max_attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	// Approved is set when the user released a held share, it goes out whatever the
+	// publishing rules say
+	Approved bool `json:"approved,omitempty" bson:"approved,omitempty"`
 }
 
 type Blog struct {
@@ -76,8 +127,157 @@ type SharedBlog struct {
 
 type ScheduledBlog struct {
 	Blog
-	Platforms     []string  `json:"platforms" bson:"platforms"`
+	// TaskId identifies this schedule, a blog can be scheduled any number of times
+	TaskId string `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	// LocalTime is a wall clock time in Timezone, it is resolved to ScheduledTime when
+	// the blog is scheduled
+	LocalTime string `json:"local_time,omitempty" bson:"-"`
+	// Timezone is the zone the schedule was made in, recurrences keep their wall clock
+	// time in it across DST changes
+	Timezone      string      `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Platforms     []string    `json:"platforms" bson:"platforms"`
+	ScheduledTime time.Time   `json:"scheduled_time" bson:"scheduled_time"`
+	Status        string      `json:"status,omitempty" bson:"status,omitempty"`
+	LastError     string      `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Recurrence    *Recurrence `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// Targets let every platform go out at its own time, Platforms and ScheduledTime
+	// are kept in sync with them (the earliest target time)
+	Targets []PlatformTarget `json:"targets,omitempty" bson:"targets,omitempty"`
+	// Copy is generated by the first target that fires and reused by the others
+	Copy *ShareCopy `json:"copy,omitempty" bson:"copy,omitempty"`
+	// CatchUp overrides the user's catch up policy for this blog
+	CatchUp *CatchUpPolicy `json:"catch_up,omitempty" bson:"catch_up,omitempty"`
+	// Queued blogs get their target times from the user's posting slots, in the order
+	// they were queued
+	Queued   bool      `json:"queued,omitempty" bson:"queued,omitempty"`
+	QueuedAt time.Time `json:"queued_at,omitempty" bson:"queued_at,omitempty"`
+}
+
+// NewTaskId returns the id of a new schedule.
+func NewTaskId() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// LoadTimezone loads an IANA timezone, an empty name is UTC. The server's own zone
+// ("Local") isn't accepted, it means something else on every machine.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("invalid timezone: %s", name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", name)
+	}
+	return location, nil
+}
+
+var localTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+// ResolveLocalTime settles the timezone of the schedule, its own or else userZone, and
+// turns LocalTime into ScheduledTime when it is set.
+func (sb *ScheduledBlog) ResolveLocalTime(userZone string) error {
+	zone := sb.Timezone
+	if zone == "" {
+		zone = userZone
+	}
+	location, err := LoadTimezone(zone)
+	if err != nil {
+		return err
+	}
+	sb.Timezone = location.String()
+	if sb.LocalTime == "" {
+		return nil
+	}
+
+	for _, layout := range localTimeLayouts {
+		if at, err := time.ParseInLocation(layout, sb.LocalTime, location); err == nil {
+			sb.ScheduledTime = at.UTC()
+			sb.LocalTime = ""
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid local_time format, expected YYYY-MM-DD HH:mm")
+}
+
+// Location is the timezone of the schedule, UTC for schedules from before they had one.
+func (sb ScheduledBlog) Location() *time.Location {
+	location, err := LoadTimezone(sb.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// TaskKey identifies the schedule. Schedules from before they had a task id are
+// identified by their blog, a user could only schedule a blog once back then.
+func (b ScheduledBlog) TaskKey() string {
+	if b.TaskId != "" {
+		return b.TaskId
+	}
+	return b.Id
+}
+
+type PlatformTarget struct {
+	Platform      string    `json:"platform" bson:"platform"`
 	ScheduledTime time.Time `json:"scheduled_time" bson:"scheduled_time"`
+	Status        string    `json:"status,omitempty" bson:"status,omitempty"`
+	Result        string    `json:"result,omitempty" bson:"result,omitempty"`
+	SharedAt      time.Time `json:"shared_at,omitempty" bson:"shared_at,omitempty"`
+}
+
+// Settled tells whether the target already went out, gave up or was skipped, settled
+// targets can't be rescheduled.
+func (t PlatformTarget) Settled() bool {
+	return t.Status == TaskStatusShared || t.Status == TaskStatusFailed || t.Status == TaskStatusSkipped
+}
+
+// ShareCopy is the AI generated text of a share per platform.
+type ShareCopy struct {
+	Blog        Blog              `json:"blog" bson:"blog"`
+	Posts       map[string]string `json:"posts" bson:"posts"`
+	Occurrence  int               `json:"occurrence" bson:"occurrence"`
+	GeneratedAt time.Time         `json:"generated_at" bson:"generated_at"`
+	// PromptHash and ResponseHash are sha256 hashes of the AI prompt and answer, they
+	// tell apart runs that got a different copy without keeping the text around
+	PromptHash   string `json:"prompt_hash,omitempty" bson:"prompt_hash,omitempty"`
+	ResponseHash string `json:"response_hash,omitempty" bson:"response_hash,omitempty"`
+}
+
+// HasPosts tells whether the copy has a post for every platform, a platform connected
+// after the copy was generated has none.
+func (c *ShareCopy) HasPosts(platforms []string) bool {
+	for _, platform := range platforms {
+		if c.Posts[platform] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Recurrence describes how an evergreen blog keeps getting re-shared after its first
+// ScheduledTime. Exactly one of IntervalDays, Weekdays or Cron is set, and the schedule
+// ends at EndDate or after Count occurrences, whichever comes first.
+type Recurrence struct {
+	IntervalDays int       `json:"interval_days,omitempty" bson:"interval_days,omitempty"`
+	Weekdays     []string  `json:"weekdays,omitempty" bson:"weekdays,omitempty"`
+	Cron         string    `json:"cron,omitempty" bson:"cron,omitempty"`
+	EndDate      time.Time `json:"end_date,omitempty" bson:"end_date,omitempty"`
+	Count        int       `json:"count,omitempty" bson:"count,omitempty"`
+	Occurrences  int       `json:"occurrences" bson:"occurrences"`
+}
+
+const (
+	CatchUpFireLate       = "fire_late"
+	CatchUpSkip           = "skip"
+	CatchUpSkipAfterGrace = "skip_after_grace"
+)
+
+// CatchUpPolicy decides what happens to a share whose time passed while the backend
+// was down: it goes out late, is skipped, or is skipped once it is later than
+// GraceMinutes. Without a policy shares go out late.
+type CatchUpPolicy struct {
+	Mode         string `json:"mode" bson:"mode"`
+	GraceMinutes int    `json:"grace_minutes,omitempty" bson:"grace_minutes,omitempty"`
 }
 
 type GraphQLQuery struct {
@@ -156,7 +356,33 @@ func (b *Blog) ValidateBase() error {
 	return nil
 }
 
-func (sb *ScheduledBlog) Validate() error {
+// NormalizeTargets fills Targets from Platforms and ScheduledTime when a schedule
+// doesn't set per platform times, otherwise it derives Platforms and ScheduledTime
+// from the targets.
+func (sb *ScheduledBlog) NormalizeTargets() {
+	if len(sb.Targets) == 0 {
+		for _, platform := range sb.Platforms {
+			sb.Targets = append(sb.Targets, PlatformTarget{Platform: platform, ScheduledTime: sb.ScheduledTime})
+		}
+		return
+	}
+
+	sb.Platforms = nil
+	first := true
+	for _, target := range sb.Targets {
+		sb.Platforms = append(sb.Platforms, target.Platform)
+		if target.Settled() {
+			continue
+		}
+		if first || target.ScheduledTime.Before(sb.ScheduledTime) {
+			sb.ScheduledTime = target.ScheduledTime
+			first = false
+		}
+	}
+}
+
+// Validate checks the blog against the time on c, the scheduler's clock in production.
+func (sb *ScheduledBlog) Validate(c clock.Clock) error {
 
 	if err := sb.Blog.ValidateBase(); err != nil {
 		return err
@@ -166,22 +392,195 @@ func (sb *ScheduledBlog) Validate() error {
 		return fmt.Errorf("at least one platform is required")
 	}
 
-	scheduledTime, err := time.Parse(time.RFC3339, sb.ScheduledTime.Format(time.RFC3339))
+	// queued blogs can sit further out, a long queue fills slots weeks ahead
+	horizon := maxScheduleAhead
+	if sb.Queued {
+		horizon = maxQueueAhead
+	}
+	if err := validateScheduledTime(sb.ScheduledTime, c.Now(), horizon); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(sb.Targets))
+	for _, target := range sb.Targets {
+		if strings.TrimSpace(target.Platform) == "" {
+			return fmt.Errorf("platform is required for every target")
+		}
+		if !KnownPlatform(target.Platform) {
+			return fmt.Errorf("platform %s is not supported", target.Platform)
+		}
+		if seen[target.Platform] {
+			return fmt.Errorf("platform %s is targeted more than once", target.Platform)
+		}
+		seen[target.Platform] = true
+		if target.Settled() {
+			continue
+		}
+		if err := validateScheduledTime(target.ScheduledTime, c.Now(), horizon); err != nil {
+			return fmt.Errorf("%s: %v", target.Platform, err)
+		}
+	}
+
+	if sb.Recurrence != nil {
+		if err := sb.Recurrence.Validate(sb.ScheduledTime); err != nil {
+			return err
+		}
+	}
+
+	if sb.CatchUp != nil {
+		if err := sb.CatchUp.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const (
+	maxScheduleAhead = 7 * 24 * time.Hour
+	maxQueueAhead    = 8 * 7 * 24 * time.Hour
+)
+
+func validateScheduledTime(t time.Time, now time.Time, horizon time.Duration) error {
+	scheduledTime, err := time.Parse(time.RFC3339, t.Format(time.RFC3339))
 	if err != nil {
 		return fmt.Errorf("invalid scheduled_time format, expected YYYY-MM-DD HH:mm")
 	}
-	currentTime := time.Now()
-	diff := scheduledTime.Sub(currentTime)
+	diff := scheduledTime.Sub(now)
 
-	if diff > (7 * 24 * time.Hour) {
-		return fmt.Errorf("scheduled time is more than 7 days from now")
+	if diff > horizon {
+		return fmt.Errorf("scheduled time is more than %d days from now", int(horizon.Hours()/24))
 	} else if diff < 0 {
 		return fmt.Errorf("scheduled time is in the past")
 	}
+	return nil
+}
+
+func (r *Recurrence) Validate(start time.Time) error {
+	rules := 0
+	if r.IntervalDays != 0 {
+		rules++
+		if r.IntervalDays < 1 || r.IntervalDays > 365 {
+			return fmt.Errorf("recurrence interval_days must be between 1 and 365")
+		}
+	}
+	if len(r.Weekdays) > 0 {
+		rules++
+		for _, day := range r.Weekdays {
+			if _, ok := parseWeekday(day); !ok {
+				return fmt.Errorf("invalid recurrence weekday: %s", day)
+			}
+		}
+	}
+	if r.Cron != "" {
+		rules++
+		if _, err := cron.ParseStandard(r.Cron); err != nil {
+			return fmt.Errorf("invalid recurrence cron expression: %v", err)
+		}
+	}
+	if rules != 1 {
+		return fmt.Errorf("recurrence needs exactly one of interval_days, weekdays or cron")
+	}
+
+	if r.EndDate.IsZero() && r.Count <= 0 {
+		return fmt.Errorf("recurrence needs an end_date or a count")
+	}
+	if !r.EndDate.IsZero() && !r.EndDate.After(start) {
+		return fmt.Errorf("recurrence end_date must be after the scheduled time")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("recurrence count can't be negative")
+	}
 
 	return nil
 }
 
+// Next returns the occurrence that follows prev, the second return value is false
+// once the recurrence has run out of occurrences or passed its end date. Days, weekdays
+// and cron fields are counted in the location of prev, so pass it in the zone of the
+// schedule to keep the wall clock time across DST changes.
+func (r *Recurrence) Next(prev time.Time) (time.Time, bool) {
+	if r.Count > 0 && r.Occurrences >= r.Count {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+	switch {
+	case r.IntervalDays > 0:
+		next = prev.AddDate(0, 0, r.IntervalDays)
+	case len(r.Weekdays) > 0:
+		days := make(map[time.Weekday]bool, len(r.Weekdays))
+		for _, day := range r.Weekdays {
+			if weekday, ok := parseWeekday(day); ok {
+				days[weekday] = true
+			}
+		}
+		if len(days) == 0 {
+			return time.Time{}, false
+		}
+		// keeps the time of day of the previous occurrence
+		next = prev.AddDate(0, 0, 1)
+		for !days[next.Weekday()] {
+			next = next.AddDate(0, 0, 1)
+		}
+	case r.Cron != "":
+		schedule, err := cron.ParseStandard(r.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		next = schedule.Next(prev)
+	default:
+		return time.Time{}, false
+	}
+
+	if next.IsZero() || (!r.EndDate.IsZero() && next.After(r.EndDate)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func parseWeekday(day string) (time.Weekday, bool) {
+	day = strings.ToLower(strings.TrimSpace(day))
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if day == name || day == name[:3] {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+func (p *CatchUpPolicy) Validate() error {
+	switch p.Mode {
+	case CatchUpFireLate, CatchUpSkip:
+		if p.GraceMinutes != 0 {
+			return fmt.Errorf("catch up grace_minutes only applies to %s", CatchUpSkipAfterGrace)
+		}
+	case CatchUpSkipAfterGrace:
+		if p.GraceMinutes < 1 || p.GraceMinutes > 7*24*60 {
+			return fmt.Errorf("catch up grace_minutes must be between 1 and %d", 7*24*60)
+		}
+	default:
+		return fmt.Errorf("catch up mode must be one of %s, %s or %s", CatchUpFireLate, CatchUpSkip, CatchUpSkipAfterGrace)
+	}
+	return nil
+}
+
+// ShouldSkip tells whether a share that is late by the given duration is skipped, a
+// nil policy fires late.
+func (p *CatchUpPolicy) ShouldSkip(late time.Duration) bool {
+	if p == nil {
+		return false
+	}
+	switch p.Mode {
+	case CatchUpSkip:
+		return true
+	case CatchUpSkipAfterGrace:
+		return late > time.Duration(p.GraceMinutes)*time.Minute
+	}
+	return false
+}
+
 func (shb *SharedBlog) Validate() error {
 	if err := shb.Blog.ValidateBase(); err != nil {
 		return err
@@ -194,6 +593,15 @@ func (shb *SharedBlog) Validate() error {
 	return nil
 }
 
+// RunAt returns the time the task is due, which is the next retry time once a
+// share has failed at least once.
+func (t ScheduledBlogData) RunAt() time.Time {
+	if !t.NextAttemptAt.IsZero() {
+		return t.NextAttemptAt
+	}
+	return t.ScheduledBlog.ScheduledTime
+}
+
 func isValidURL(str string) bool {
 	u, err := url.Parse(str)
 	return err == nil && u.Scheme != "" && u.Host != ""
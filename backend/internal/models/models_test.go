@@ -0,0 +1,230 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/clock"
+)
+
+// the publishers register their platforms from services, which models can't import
+func init() {
+	RegisterPlatform("twitter")
+	RegisterPlatform("linkedin")
+}
+
+func TestRecurrenceNext_IntervalDays(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC)
+	r := &Recurrence{IntervalDays: 14, Count: 3, Occurrences: 1}
+
+	next, ok := r.Next(start)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 17, 9, 30, 0, 0, time.UTC), next)
+}
+
+func TestRecurrenceNext_Weekdays(t *testing.T) {
+	// 2025-03-04 is a Tuesday
+	start := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+	r := &Recurrence{Weekdays: []string{"tue", "Thursday"}, Count: 10, Occurrences: 1}
+
+	next, ok := r.Next(start)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 6, 9, 30, 0, 0, time.UTC), next)
+
+	next, ok = r.Next(next)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 11, 9, 30, 0, 0, time.UTC), next)
+}
+
+func TestRecurrenceNext_Cron(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	r := &Recurrence{Cron: "0 10 1 * *", EndDate: start.AddDate(1, 0, 0)}
+
+	next, ok := r.Next(start)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 4, 1, 10, 0, 0, 0, time.UTC), next)
+}
+
+func TestRecurrenceNext_Ends(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	byCount := &Recurrence{IntervalDays: 7, Count: 2, Occurrences: 2}
+	_, ok := byCount.Next(start)
+	assert.False(t, ok)
+
+	byDate := &Recurrence{IntervalDays: 7, EndDate: start.AddDate(0, 0, 5)}
+	_, ok = byDate.Next(start)
+	assert.False(t, ok)
+}
+
+func TestRecurrenceValidate(t *testing.T) {
+	start := time.Now().Add(time.Hour)
+
+	assert.NoError(t, (&Recurrence{IntervalDays: 7, Count: 4}).Validate(start))
+	assert.Error(t, (&Recurrence{IntervalDays: 7}).Validate(start))
+	assert.Error(t, (&Recurrence{IntervalDays: 7, Weekdays: []string{"mon"}, Count: 4}).Validate(start))
+	assert.Error(t, (&Recurrence{Weekdays: []string{"someday"}, Count: 4}).Validate(start))
+	assert.Error(t, (&Recurrence{Cron: "not a cron", Count: 4}).Validate(start))
+	assert.Error(t, (&Recurrence{IntervalDays: 7, EndDate: start.Add(-time.Hour)}).Validate(start))
+}
+
+func TestScheduledBlogNormalizeTargets(t *testing.T) {
+	at := time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC)
+
+	legacy := &ScheduledBlog{Platforms: []string{"twitter", "linkedin"}, ScheduledTime: at}
+	legacy.NormalizeTargets()
+	assert.Len(t, legacy.Targets, 2)
+	assert.Equal(t, at, legacy.Targets[1].ScheduledTime)
+
+	targeted := &ScheduledBlog{Targets: []PlatformTarget{
+		{Platform: "linkedin", ScheduledTime: at.Add(3 * time.Hour)},
+		{Platform: "twitter", ScheduledTime: at},
+	}}
+	targeted.NormalizeTargets()
+	assert.Equal(t, []string{"linkedin", "twitter"}, targeted.Platforms)
+	assert.Equal(t, at, targeted.ScheduledTime)
+}
+
+func TestScheduledBlogValidate_UsesClock(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	blog := ScheduledBlog{ScheduledTime: now.Add(time.Hour), Platforms: []string{"twitter"}}
+	blog.Id = "blog1"
+	blog.Title = "A blog"
+	blog.Url = "https://example.com/a-blog"
+	blog.Author.Name = "someone"
+	blog.CoverImage.URL = "https://example.com/cover.png"
+	blog.NormalizeTargets()
+	assert.NoError(t, blog.Validate(fake))
+
+	fake.Advance(2 * time.Hour)
+	assert.EqualError(t, blog.Validate(fake), "scheduled time is in the past")
+
+	fake.Set(now.Add(-8 * 24 * time.Hour))
+	assert.EqualError(t, blog.Validate(fake), "scheduled time is more than 7 days from now")
+	blog.Queued = true
+	assert.NoError(t, blog.Validate(fake))
+
+	blog.Targets[0].Platform = "myspace"
+	assert.EqualError(t, blog.Validate(fake), "platform myspace is not supported")
+}
+
+func TestCatchUpPolicy(t *testing.T) {
+	var none *CatchUpPolicy
+	assert.False(t, none.ShouldSkip(time.Hour))
+	assert.False(t, (&CatchUpPolicy{Mode: CatchUpFireLate}).ShouldSkip(time.Hour))
+	assert.True(t, (&CatchUpPolicy{Mode: CatchUpSkip}).ShouldSkip(5*time.Minute))
+
+	grace := &CatchUpPolicy{Mode: CatchUpSkipAfterGrace, GraceMinutes: 30}
+	assert.NoError(t, grace.Validate())
+	assert.False(t, grace.ShouldSkip(20*time.Minute))
+	assert.True(t, grace.ShouldSkip(45*time.Minute))
+
+	assert.Error(t, (&CatchUpPolicy{Mode: "sometimes"}).Validate())
+	assert.Error(t, (&CatchUpPolicy{Mode: CatchUpSkipAfterGrace}).Validate())
+	assert.Error(t, (&CatchUpPolicy{Mode: CatchUpSkip, GraceMinutes: 10}).Validate())
+}
+
+func TestPostingScheduleNextSlot(t *testing.T) {
+	schedule := &PostingSchedule{
+		Timezone: "Asia/Kolkata",
+		Slots: []PostingSlot{
+			{Platform: "twitter", Weekday: "tue", Time: "09:30"},
+			{Platform: "twitter", Weekday: "thursday", Time: "09:30"},
+			{Platform: "linkedin", Weekday: "mon", Time: "18:00"},
+		},
+	}
+	assert.NoError(t, schedule.Validate())
+	kolkata := schedule.Location()
+
+	// a wednesday, the next twitter slot is thursday
+	wednesday := time.Date(2025, 3, 12, 12, 0, 0, 0, kolkata)
+	next, ok := schedule.NextSlot("twitter", wednesday)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 13, 9, 30, 0, 0, kolkata).UTC(), next)
+
+	// right at a slot the following one is taken
+	next, ok = schedule.NextSlot("twitter", time.Date(2025, 3, 13, 9, 30, 0, 0, kolkata))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 18, 9, 30, 0, 0, kolkata).UTC(), next)
+
+	// a slot earlier in the day of the same weekday wraps to next week
+	next, ok = schedule.NextSlot("linkedin", time.Date(2025, 3, 10, 19, 0, 0, 0, kolkata))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 17, 18, 0, 0, 0, kolkata).UTC(), next)
+
+	_, ok = schedule.NextSlot("mastodon", wednesday)
+	assert.False(t, ok)
+
+	assert.Error(t, (&PostingSchedule{Timezone: "Mars/Olympus"}).Validate())
+	assert.Error(t, (&PostingSchedule{Timezone: "UTC", Slots: []PostingSlot{{Platform: "twitter", Weekday: "tue", Time: "9.30"}}}).Validate())
+}
+
+func TestResolveLocalTime(t *testing.T) {
+	blog := ScheduledBlog{LocalTime: "2025-03-28 09:00"}
+	assert.NoError(t, blog.ResolveLocalTime("Europe/Berlin"))
+	assert.Equal(t, "Europe/Berlin", blog.Timezone)
+	assert.Equal(t, time.Date(2025, 3, 28, 8, 0, 0, 0, time.UTC), blog.ScheduledTime)
+
+	// the zone sent with the schedule wins over the user's
+	blog = ScheduledBlog{LocalTime: "2025-03-28T09:00", Timezone: "America/New_York"}
+	assert.NoError(t, blog.ResolveLocalTime("Europe/Berlin"))
+	assert.Equal(t, time.Date(2025, 3, 28, 13, 0, 0, 0, time.UTC), blog.ScheduledTime)
+
+	assert.Error(t, (&ScheduledBlog{LocalTime: "28/03/2025 09:00"}).ResolveLocalTime("UTC"))
+	assert.Error(t, (&ScheduledBlog{}).ResolveLocalTime("Local"))
+	assert.Error(t, (&ScheduledBlog{}).ResolveLocalTime("Europe/Atlantis"))
+}
+
+func TestRecurrenceNext_KeepsWallClockAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	r := &Recurrence{IntervalDays: 7, Count: 5}
+
+	// 09:00 CET is 08:00 UTC, a week later clocks went forward and 09:00 CEST is 07:00 UTC
+	start := time.Date(2025, 3, 27, 8, 0, 0, 0, time.UTC)
+	next, ok := r.Next(start.In(berlin))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 4, 3, 7, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestPublishingRulesNextAllowed(t *testing.T) {
+	rules := &PublishingRules{
+		Timezone: "UTC",
+		Action:   BlackoutDefer,
+		QuietHours: []QuietHours{
+			{Start: "22:00", End: "07:00"},
+			{Weekdays: []string{"sat", "sun"}, Start: "00:00", End: "00:00"},
+		},
+		Blackouts: []BlackoutDate{{From: "2025-03-12", To: "2025-03-13", Reason: "offsite"}},
+	}
+	assert.NoError(t, rules.Validate())
+
+	// a tuesday afternoon is allowed
+	tuesday := time.Date(2025, 3, 11, 15, 0, 0, 0, time.UTC)
+	at, reason := rules.NextAllowed(tuesday)
+	assert.Equal(t, tuesday, at)
+	assert.Empty(t, reason)
+
+	// tuesday night runs into the blackout, which ends friday morning in quiet hours
+	at, reason = rules.NextAllowed(time.Date(2025, 3, 11, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, "quiet hours", reason)
+	assert.Equal(t, time.Date(2025, 3, 14, 7, 0, 0, 0, time.UTC), at)
+
+	at, reason = rules.NextAllowed(time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, "blackout: offsite", reason)
+	assert.Equal(t, time.Date(2025, 3, 14, 7, 0, 0, 0, time.UTC), at)
+
+	// the weekend is quiet as a whole
+	at, _ = rules.NextAllowed(time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 3, 17, 7, 0, 0, 0, time.UTC), at)
+
+	always := &PublishingRules{Action: BlackoutDefer, QuietHours: []QuietHours{{Start: "09:00", End: "09:00"}}}
+	at, reason = always.NextAllowed(tuesday)
+	assert.True(t, at.IsZero())
+	assert.Equal(t, "quiet hours", reason)
+
+	assert.Error(t, (&PublishingRules{Action: "ignore"}).Validate())
+	assert.Error(t, (&PublishingRules{Action: BlackoutHold, Blackouts: []BlackoutDate{{From: "2025-03-13", To: "2025-03-12"}}}).Validate())
+}
@@ -0,0 +1,23 @@
+package models
+
+import "sync"
+
+var (
+	platformsMu sync.RWMutex
+	platforms   = map[string]bool{}
+)
+
+// RegisterPlatform makes a platform valid in schedules and posting slots, the
+// services package registers one for every publisher it has.
+func RegisterPlatform(name string) {
+	platformsMu.Lock()
+	defer platformsMu.Unlock()
+	platforms[name] = true
+}
+
+// KnownPlatform tells whether blogs can be shared to the platform.
+func KnownPlatform(name string) bool {
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+	return platforms[name]
+}
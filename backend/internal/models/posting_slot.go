@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const slotTimeLayout = "15:04"
+
+// PostingSlot is a weekly time a platform posts at, like tuesdays at 09:30.
+type PostingSlot struct {
+	Platform string `json:"platform" bson:"platform"`
+	Weekday  string `json:"weekday" bson:"weekday"`
+	Time     string `json:"time" bson:"time"`
+}
+
+// PostingSchedule holds the weekly posting slots of a user, slot times are wall clock
+// times in Timezone, or in the user's timezone when it is empty.
+type PostingSchedule struct {
+	Timezone string        `json:"timezone" bson:"timezone"`
+	Slots    []PostingSlot `json:"slots" bson:"slots"`
+}
+
+func (p *PostingSchedule) Validate() error {
+	if _, err := LoadTimezone(p.Timezone); err != nil {
+		return err
+	}
+	seen := make(map[PostingSlot]bool, len(p.Slots))
+	for _, slot := range p.Slots {
+		if strings.TrimSpace(slot.Platform) == "" {
+			return fmt.Errorf("platform is required for every posting slot")
+		}
+		if !KnownPlatform(slot.Platform) {
+			return fmt.Errorf("platform %s is not supported", slot.Platform)
+		}
+		if _, ok := parseWeekday(slot.Weekday); !ok {
+			return fmt.Errorf("invalid posting slot weekday: %s", slot.Weekday)
+		}
+		if _, err := time.Parse(slotTimeLayout, slot.Time); err != nil {
+			return fmt.Errorf("invalid posting slot time %s, expected HH:mm", slot.Time)
+		}
+		if seen[slot] {
+			return fmt.Errorf("posting slot %s %s %s is defined more than once", slot.Platform, slot.Weekday, slot.Time)
+		}
+		seen[slot] = true
+	}
+	return nil
+}
+
+// Location is the timezone of the slots, UTC when it isn't set.
+func (p *PostingSchedule) Location() *time.Location {
+	if p == nil {
+		return time.UTC
+	}
+	location, err := LoadTimezone(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// NextSlot returns the first slot of the platform after the given time, the second
+// return value is false when the platform has no slots.
+func (p *PostingSchedule) NextSlot(platform string, after time.Time) (time.Time, bool) {
+	if p == nil {
+		return time.Time{}, false
+	}
+	location := p.Location()
+	local := after.In(location)
+
+	var next time.Time
+	for _, slot := range p.Slots {
+		if slot.Platform != platform {
+			continue
+		}
+		weekday, ok := parseWeekday(slot.Weekday)
+		clock, err := time.Parse(slotTimeLayout, slot.Time)
+		if !ok || err != nil {
+			continue
+		}
+		days := (int(weekday) - int(local.Weekday()) + 7) % 7
+		at := time.Date(local.Year(), local.Month(), local.Day()+days, clock.Hour(), clock.Minute(), 0, 0, location)
+		if !at.After(after) {
+			at = time.Date(local.Year(), local.Month(), local.Day()+days+7, clock.Hour(), clock.Minute(), 0, 0, location)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	if next.IsZero() {
+		return time.Time{}, false
+	}
+	return next.UTC(), true
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// BlackoutDefer moves a share that lands in quiet hours or a blackout to the next
+	// allowed time, BlackoutHold keeps it until the user releases it
+	BlackoutDefer = "defer"
+	BlackoutHold  = "hold"
+
+	blackoutDateLayout = "2006-01-02"
+	// maxBlockedWindows bounds the search for an allowed time, rules that block every
+	// hour of the week never find one
+	maxBlockedWindows = 1000
+)
+
+// QuietHours block publishing from Start to End on the given weekdays, or every day when
+// there are none. An End before Start runs past midnight, an End equal to Start blocks
+// the whole day.
+type QuietHours struct {
+	Weekdays []string `json:"weekdays,omitempty" bson:"weekdays,omitempty"`
+	Start    string   `json:"start" bson:"start"`
+	End      string   `json:"end" bson:"end"`
+}
+
+// BlackoutDate blocks publishing on every day from From to To, both YYYY-MM-DD and
+// inclusive. An empty To is a single day.
+type BlackoutDate struct {
+	From   string `json:"from" bson:"from"`
+	To     string `json:"to,omitempty" bson:"to,omitempty"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// PublishingRules say when the user's scheduled shares may not go out and what happens
+// to the ones that are due then. Times are wall clock times in Timezone, or in the
+// user's timezone when it is empty.
+type PublishingRules struct {
+	Timezone   string         `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	QuietHours []QuietHours   `json:"quiet_hours,omitempty" bson:"quiet_hours,omitempty"`
+	Blackouts  []BlackoutDate `json:"blackouts,omitempty" bson:"blackouts,omitempty"`
+	Action     string         `json:"action" bson:"action"`
+}
+
+func (r *PublishingRules) Validate() error {
+	if _, err := LoadTimezone(r.Timezone); err != nil {
+		return err
+	}
+	switch r.Action {
+	case BlackoutDefer, BlackoutHold:
+	default:
+		return fmt.Errorf("invalid publishing rules action: %s", r.Action)
+	}
+	for _, quiet := range r.QuietHours {
+		for _, day := range quiet.Weekdays {
+			if _, ok := parseWeekday(day); !ok {
+				return fmt.Errorf("invalid quiet hours weekday: %s", day)
+			}
+		}
+		if _, err := time.Parse(slotTimeLayout, quiet.Start); err != nil {
+			return fmt.Errorf("invalid quiet hours start %s, expected HH:mm", quiet.Start)
+		}
+		if _, err := time.Parse(slotTimeLayout, quiet.End); err != nil {
+			return fmt.Errorf("invalid quiet hours end %s, expected HH:mm", quiet.End)
+		}
+	}
+	for _, blackout := range r.Blackouts {
+		from, err := time.Parse(blackoutDateLayout, blackout.From)
+		if err != nil {
+			return fmt.Errorf("invalid blackout date %s, expected YYYY-MM-DD", blackout.From)
+		}
+		if blackout.To == "" {
+			continue
+		}
+		to, err := time.Parse(blackoutDateLayout, blackout.To)
+		if err != nil {
+			return fmt.Errorf("invalid blackout date %s, expected YYYY-MM-DD", blackout.To)
+		}
+		if to.Before(from) {
+			return fmt.Errorf("blackout %s ends before it starts", blackout.From)
+		}
+	}
+	return nil
+}
+
+// Location is the timezone of the rules, UTC when it isn't set.
+func (r *PublishingRules) Location() *time.Location {
+	location, err := LoadTimezone(r.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// NextAllowed returns the first time at or after t that no quiet hours or blackout
+// block, and why t is blocked. When t is allowed it returns t and an empty reason, when
+// no allowed time is found the time is zero.
+func (r *PublishingRules) NextAllowed(t time.Time) (time.Time, string) {
+	if r == nil {
+		return t, ""
+	}
+	until, reason, blocked := r.blockedUntil(t)
+	if !blocked {
+		return t, ""
+	}
+	for i := 0; i < maxBlockedWindows; i++ {
+		next, _, stillBlocked := r.blockedUntil(until)
+		if !stillBlocked {
+			return until.UTC(), reason
+		}
+		until = next
+	}
+	return time.Time{}, reason
+}
+
+// blockedUntil returns the end of the quiet hours or blackout t falls in.
+func (r *PublishingRules) blockedUntil(t time.Time) (time.Time, string, bool) {
+	location := r.Location()
+	local := t.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	for _, blackout := range r.Blackouts {
+		from, err := time.ParseInLocation(blackoutDateLayout, blackout.From, location)
+		if err != nil {
+			continue
+		}
+		to := from
+		if blackout.To != "" {
+			if to, err = time.ParseInLocation(blackoutDateLayout, blackout.To, location); err != nil {
+				continue
+			}
+		}
+		end := to.AddDate(0, 0, 1)
+		if !t.Before(from) && t.Before(end) {
+			reason := "blackout"
+			if blackout.Reason != "" {
+				reason += ": " + blackout.Reason
+			}
+			return end, reason, true
+		}
+	}
+
+	for _, quiet := range r.QuietHours {
+		start, err := time.Parse(slotTimeLayout, quiet.Start)
+		if err != nil {
+			continue
+		}
+		stop, err := time.Parse(slotTimeLayout, quiet.End)
+		if err != nil {
+			continue
+		}
+		// the quiet hours of yesterday may run into today
+		for _, offset := range []int{-1, 0} {
+			date := day.AddDate(0, 0, offset)
+			if !quiet.onWeekday(date.Weekday()) {
+				continue
+			}
+			from := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, location)
+			to := time.Date(date.Year(), date.Month(), date.Day(), stop.Hour(), stop.Minute(), 0, 0, location)
+			if !to.After(from) {
+				to = to.AddDate(0, 0, 1)
+			}
+			if !t.Before(from) && t.Before(to) {
+				return to, "quiet hours", true
+			}
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func (q QuietHours) onWeekday(weekday time.Weekday) bool {
+	if len(q.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range q.Weekdays {
+		if parsed, ok := parseWeekday(day); ok && parsed == weekday {
+			return true
+		}
+	}
+	return false
+}
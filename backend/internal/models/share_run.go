@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ShareTriggerImmediate = "immediate"
+	ShareTriggerScheduled = "scheduled"
+
+	// PlatformNotAttempted is a platform the run never got to because an earlier one failed
+	PlatformNotAttempted = "not_attempted"
+)
+
+// PlatformOutcome is what happened on one platform during a share run, Status is
+// shared, failed, skipped or not_attempted.
+type PlatformOutcome struct {
+	Platform string `json:"platform" bson:"platform"`
+	Status   string `json:"status" bson:"status"`
+	PostId   string `json:"post_id,omitempty" bson:"post_id,omitempty"`
+	Error    string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// ShareRun records one execution of a share, immediate or scheduled, whether it went
+// out or not.
+type ShareRun struct {
+	Id           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID       string             `json:"user_id" bson:"user_id"`
+	BlogId       string             `json:"blog_id" bson:"blog_id"`
+	BlogTitle    string             `json:"blog_title,omitempty" bson:"blog_title,omitempty"`
+	TaskId       string             `json:"task_id,omitempty" bson:"task_id,omitempty"`
+	Trigger      string             `json:"trigger" bson:"trigger"`
+	Attempt      int                `json:"attempt,omitempty" bson:"attempt,omitempty"`
+	Occurrence   int                `json:"occurrence,omitempty" bson:"occurrence,omitempty"`
+	Platforms    []PlatformOutcome  `json:"platforms" bson:"platforms"`
+	Error        string             `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt    time.Time          `json:"started_at" bson:"started_at"`
+	DurationMs   int64              `json:"duration_ms" bson:"duration_ms"`
+	PromptHash   string             `json:"prompt_hash,omitempty" bson:"prompt_hash,omitempty"`
+	ResponseHash string             `json:"response_hash,omitempty" bson:"response_hash,omitempty"`
+}
+
+// ShareRunQuery filters and pages the share runs of a user, zero values don't filter.
+type ShareRunQuery struct {
+	BlogId string
+	From   time.Time
+	To     time.Time
+	Page   int
+	Limit  int
+}
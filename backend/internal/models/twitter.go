@@ -0,0 +1,28 @@
+package models
+
+import "fmt"
+
+const (
+	XLinkFirst = "first"
+	XLinkLast  = "last"
+
+	// XMaxThreadTweets is the longest thread a share goes out as
+	XMaxThreadTweets = 10
+)
+
+// XThreadSettings turn copy that doesn't fit in a tweet into a thread of numbered
+// replies instead of cutting it off.
+type XThreadSettings struct {
+	Enabled bool `json:"enabled" bson:"enabled"`
+	// LinkPlacement is the tweet the blog link goes in, first or last, empty is first
+	LinkPlacement string `json:"link_placement" bson:"link_placement,omitempty"`
+}
+
+func (x *XThreadSettings) Validate() error {
+	switch x.LinkPlacement {
+	case "", XLinkFirst, XLinkLast:
+	default:
+		return fmt.Errorf("invalid link_placement: %s, expected first or last", x.LinkPlacement)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"social-scribe/backend/internal/models"
+)
+
+var (
+	// GetCrossPost returns the cross post of the blog on the platform, nil if it was never
+	// cross posted there
+	GetCrossPost  = defaultGetCrossPost
+	SaveCrossPost = defaultSaveCrossPost
+	// GetCrossPosts returns the user's cross posts, most recently updated first
+	GetCrossPosts = defaultGetCrossPosts
+)
+
+func createCrossPostIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := crossPostsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "blog_id", Value: 1}, {Key: "platform", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func defaultGetCrossPost(userId, blogId, platform string) (*models.CrossPost, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var crossPost models.CrossPost
+	filter := bson.M{"user_id": userId, "blog_id": blogId, "platform": platform}
+	err := crossPostsCollection.FindOne(ctx, filter).Decode(&crossPost)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to get the %s cross post of blog %s: %v", platform, blogId, err)
+		return nil, err
+	}
+	return &crossPost, nil
+}
+
+func defaultSaveCrossPost(crossPost *models.CrossPost) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": crossPost.UserId, "blog_id": crossPost.BlogId, "platform": crossPost.Platform}
+	_, err := crossPostsCollection.ReplaceOne(ctx, filter, crossPost, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("[ERROR] Failed to save the %s cross post of blog %s: %v", crossPost.Platform, crossPost.BlogId, err)
+		return err
+	}
+	return nil
+}
+
+func defaultGetCrossPosts(userId string) ([]models.CrossPost, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := crossPostsCollection.Find(ctx, bson.M{"user_id": userId}, findOptions)
+	if err != nil {
+		log.Printf("[ERROR] Error getting cross posts: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	crossPosts := []models.CrossPost{}
+	if err := cursor.All(ctx, &crossPosts); err != nil {
+		log.Printf("[ERROR] Error decoding cross posts: %v", err)
+		return nil, err
+	}
+	return crossPosts, nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"social-scribe/backend/internal/models"
+)
+
+var (
+	// GetMastodonApp returns the OAuth app registered on the instance, nil if there is none yet
+	GetMastodonApp  = defaultGetMastodonApp
+	SaveMastodonApp = defaultSaveMastodonApp
+)
+
+func createMastodonAppIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := mastodonAppsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "instance", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func defaultGetMastodonApp(instance string) (*models.MastodonApp, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var app models.MastodonApp
+	err := mastodonAppsCollection.FindOne(ctx, bson.M{"instance": instance}).Decode(&app)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to get the Mastodon app of %s: %v", instance, err)
+		return nil, err
+	}
+	return &app, nil
+}
+
+func defaultSaveMastodonApp(app *models.MastodonApp) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := mastodonAppsCollection.ReplaceOne(ctx, bson.M{"instance": app.Instance}, app, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("[ERROR] Failed to save the Mastodon app of %s: %v", app.Instance, err)
+		return err
+	}
+	return nil
+}
@@ -15,6 +15,9 @@ var client *mongo.Client
 var userCollection *mongo.Collection
 var cacheCollection *mongo.Collection
 var scheduledItemsCollection *mongo.Collection
+var shareRunsCollection *mongo.Collection
+var mastodonAppsCollection *mongo.Collection
+var crossPostsCollection *mongo.Collection
 
 func InitMongoDb() {
 	mongoURI := os.Getenv("MONGO_URI")
@@ -42,11 +45,23 @@ func InitMongoDb() {
 	userCollection = client.Database(dbName).Collection("users")
 	cacheCollection = client.Database(dbName).Collection("cache")
 	scheduledItemsCollection = client.Database(dbName).Collection("scheduled_items")
+	shareRunsCollection = client.Database(dbName).Collection("share_runs")
+	mastodonAppsCollection = client.Database(dbName).Collection("mastodon_apps")
+	crossPostsCollection = client.Database(dbName).Collection("cross_posts")
 
 	err = CreateIndexes()
 	if err != nil {
 		log.Println("[ERROR] Failed creating indexes:", err)
 	}
+	if err := createShareRunIndexes(); err != nil {
+		log.Println("[ERROR] Failed creating share run indexes:", err)
+	}
+	if err := createMastodonAppIndexes(); err != nil {
+		log.Println("[ERROR] Failed creating Mastodon app indexes:", err)
+	}
+	if err := createCrossPostIndexes(); err != nil {
+		log.Println("[ERROR] Failed creating cross post indexes:", err)
+	}
 	log.Println("[INFO] Successfully connected to MongoDB")
 }
 
@@ -2,64 +2,284 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"social-scribe/backend/internal/models"
 )
 
 var (
-	GetScheduledTasks   = defaultGetScheduledTasks
-	StoreScheduledTask  = defualtStoreScheduledTask
-	DeleteScheduledTask = defualtDeleteScheduledTask
+	GetScheduledJobs    = defaultGetScheduledJobs
+	StoreScheduledJob   = defaultStoreScheduledJob
+	DeleteScheduledJob  = defaultDeleteScheduledJob
+	UpdateScheduledTask = defaultUpdateScheduledTask
+	// DeleteScheduledBlogTasks drops every stored task of a schedule, whatever its platform or status
+	DeleteScheduledBlogTasks = defaultDeleteScheduledBlogTasks
+	// SaveRescheduledBlog writes a rescheduled blog to the user and to its stored tasks together
+	SaveRescheduledBlog = defaultSaveRescheduledBlog
 )
 
-func defaultGetScheduledTasks() ([]models.ScheduledBlogData, error) {
-	ctx := context.TODO()
+// transactionsUnsupported is the code MongoDB answers with when a transaction is started
+// on a standalone server.
+const transactionsUnsupported = 20
+
+// scheduleFilter matches the stored tasks of a schedule. Schedules from before they had
+// a task id are matched on the blog, leaving out the newer schedules of the same blog.
+func scheduleFilter(userId string, blog models.ScheduledBlog) bson.M {
+	if blog.TaskId != "" {
+		return bson.M{"user_id": userId, "blog.task_id": blog.TaskId}
+	}
+	return bson.M{
+		"user_id":      userId,
+		"blog.blog.id": blog.Id,
+		"blog.task_id": bson.M{"$exists": false},
+	}
+}
+
+// scheduledTaskFilter matches the stored task, tasks of a single platform target also
+// match on the platform.
+func scheduledTaskFilter(task models.ScheduledBlogData) bson.M {
+	filter := scheduleFilter(task.UserID, task.ScheduledBlog)
+	if task.Platform != "" {
+		filter["platform"] = task.Platform
+	}
+	return filter
+}
+
+// jobDocument is the stored form of a job, its own fields plus the kind and the key so
+// it can be decoded and found again, and the synced_at stamp that tells the store
+// watcher the scheduler already has it.
+func jobDocument(job models.Job) (bson.D, error) {
+	raw, err := bson.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.D
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return append(bson.D{{Key: "kind", Value: job.Kind()}, {Key: "key", Value: job.Key()}, {Key: syncedAtField, Value: time.Now()}}, fields...), nil
+}
+
+// jobFilter matches the stored job. Blog shares keep matching on the blog so documents
+// stored before jobs had a key are still found, emails also match on the _id legacy
+// emails are decoded with.
+func jobFilter(job models.Job) bson.M {
+	switch job := job.(type) {
+	case models.ScheduledBlogData:
+		return scheduledTaskFilter(job)
+	case models.EmailJob:
+		if id, err := primitive.ObjectIDFromHex(job.Id); err == nil {
+			return bson.M{"$or": []bson.M{
+				{"kind": job.Kind(), "key": job.Key()},
+				{"_id": id, "kind": bson.M{"$exists": false}},
+			}}
+		}
+	}
+	return bson.M{"kind": job.Kind(), "key": job.Key()}
+}
 
-	var scheduledTasks []models.ScheduledBlogData
+// decodeJob decodes a stored job by its kind. Emails queued before jobs had a kind
+// were stored as blog tasks with an email_id, they keep the _id of their document so
+// they can be deleted once sent.
+func decodeJob(raw bson.Raw) (models.Job, error) {
+	kind, _ := raw.Lookup("kind").StringValueOK()
+	if kind == "" {
+		if to, ok := raw.Lookup("email_id").StringValueOK(); ok && to != "" {
+			userId, _ := raw.Lookup("user_id").StringValueOK()
+			message, _ := raw.Lookup("message").StringValueOK()
+			// they were due when they were stored
+			var sendAt time.Time
+			id, ok := raw.Lookup("_id").ObjectIDOK()
+			if ok {
+				sendAt = id.Timestamp()
+			}
+			email := models.NewEmailJob(userId, to, message, sendAt)
+			if ok {
+				email.Id = id.Hex()
+			}
+			return email, nil
+		}
+	}
+	return models.DecodeJob(kind, func(v interface{}) error {
+		return bson.Unmarshal(raw, v)
+	})
+}
+
+func defaultGetScheduledJobs() ([]models.Job, error) {
+	ctx := context.TODO()
 
-	cursor, err := scheduledItemsCollection.Find(ctx, bson.M{})
+	// failed and held tasks are kept around so the user can see them, but they must not
+	// be loaded again
+	cursor, err := scheduledItemsCollection.Find(ctx, bson.M{"status": bson.M{"$nin": []string{models.TaskStatusFailed, models.TaskStatusHeld}}})
 	if err != nil {
-		log.Printf("[ERROR] Error getting scheduled tasks: %v", err)
+		log.Printf("[ERROR] Error getting scheduled jobs: %v", err)
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	if err = cursor.All(ctx, &scheduledTasks); err != nil {
-		log.Printf("[ERROR] Error decoding scheduled tasks: %v", err)
+	var jobs []models.Job
+	for cursor.Next(ctx) {
+		job, err := decodeJob(cursor.Current)
+		if err != nil {
+			log.Printf("[ERROR] Error decoding scheduled job, skipping it: %v", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("[ERROR] Error reading scheduled jobs: %v", err)
 		return nil, err
 	}
 
-	return scheduledTasks, nil
+	return jobs, nil
 }
 
-func defualtStoreScheduledTask(task models.ScheduledBlogData) error {
+func defaultStoreScheduledJob(job models.Job) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := scheduledItemsCollection.InsertOne(ctx, task)
+	doc, err := jobDocument(job)
+	if err != nil {
+		return err
+	}
+	_, err = scheduledItemsCollection.InsertOne(ctx, doc)
 	if err != nil {
-		log.Printf("[ERROR] Failed to store scheduled task: %v", err)
+		log.Printf("[ERROR] Failed to store scheduled job: %v", err)
 		return err
 	}
 	return nil
 }
 
-func defualtDeleteScheduledTask(task models.ScheduledBlogData) error {
+func defaultDeleteScheduledJob(job models.Job) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := scheduledItemsCollection.DeleteOne(ctx, bson.M{
-		"user_id":      task.UserID,
-		"blog.blog.id": task.ScheduledBlog.Id,
-	})
+	result, err := scheduledItemsCollection.DeleteOne(ctx, jobFilter(job))
 	if err != nil {
-		log.Printf("[ERROR] Failed to delete scheduled task: %v", err)
+		log.Printf("[ERROR] Failed to delete scheduled job: %v", err)
 		return err
 	}
-	log.Printf("[INFO] Deleted scheduled task, deleted count: %d", result.DeletedCount)
+	log.Printf("[INFO] Deleted scheduled %s job, deleted count: %d", job.Kind(), result.DeletedCount)
 
 	return nil
 }
+
+func defaultUpdateScheduledTask(task models.ScheduledBlogData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := scheduledTaskFilter(task)
+	update := bson.M{"$set": bson.M{
+		"blog":            task.ScheduledBlog,
+		"status":          task.Status,
+		"attempts":        task.Attempts,
+		"max_attempts":    task.MaxAttempts,
+		"next_attempt_at": task.NextAttemptAt,
+		"last_error":      task.LastError,
+		"approved":        task.Approved,
+		syncedAtField:     time.Now(),
+	}, "$unset": bson.M{syncedHashField: ""}}
+
+	result, err := scheduledItemsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		log.Printf("[ERROR] Failed to update scheduled task: %v", err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func defaultDeleteScheduledBlogTasks(userId string, blog models.ScheduledBlog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := scheduledItemsCollection.DeleteMany(ctx, scheduleFilter(userId, blog))
+	if err != nil {
+		log.Printf("[ERROR] Failed to delete scheduled tasks of schedule %s: %v", blog.TaskKey(), err)
+		return err
+	}
+	log.Printf("[INFO] Deleted scheduled tasks of schedule %s, deleted count: %d", blog.TaskKey(), result.DeletedCount)
+	return nil
+}
+
+// userScheduleFilter matches the user holding the schedule, for positional updates of
+// it in scheduled_posts.
+func userScheduleFilter(objID primitive.ObjectID, blog models.ScheduledBlog) bson.M {
+	match := bson.M{"task_id": blog.TaskId}
+	if blog.TaskId == "" {
+		match = bson.M{"blog.id": blog.Id, "task_id": bson.M{"$exists": false}}
+	}
+	return bson.M{"_id": objID, "scheduled_posts": bson.M{"$elemMatch": match}}
+}
+
+// defaultSaveRescheduledBlog replaces the user's scheduled blog, deletes the stored tasks in
+// deleted and upserts the ones of its pending targets, all in one transaction. A
+// standalone MongoDB has no transactions, there the writes are made one after another.
+func defaultSaveRescheduledBlog(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, deleted []models.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+
+	writes := func(ctx context.Context) error {
+		result, err := userCollection.UpdateOne(ctx,
+			userScheduleFilter(objID, blog),
+			bson.M{"$set": bson.M{"scheduled_posts.$": blog}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		// deletes go first, the filter of a task from before targets existed would
+		// match the upserted targets as well
+		for _, job := range deleted {
+			if _, err := scheduledItemsCollection.DeleteOne(ctx, jobFilter(job)); err != nil {
+				return err
+			}
+		}
+		for _, task := range tasks {
+			doc, err := jobDocument(task)
+			if err != nil {
+				return err
+			}
+			_, err = scheduledItemsCollection.ReplaceOne(ctx, scheduledTaskFilter(task), doc, options.Replace().SetUpsert(true))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, writes(sessCtx)
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == transactionsUnsupported {
+		log.Printf("[WARN] MongoDB doesn't support transactions, saving the rescheduled blog %s without one", blog.Id)
+		err = writes(ctx)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to save rescheduled blog %s: %v", blog.Id, err)
+		return err
+	}
+	return nil
+}
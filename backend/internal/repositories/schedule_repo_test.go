@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"social-scribe/backend/internal/models"
+)
+
+func TestLegacyEmailJob_DeletedAfterRunning(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("legacy email", func(mt *mtest.T) {
+		scheduledItemsCollection = mt.Coll
+		legacyId := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "test.scheduled_items", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: legacyId},
+			{Key: "user_id", Value: "user-1"},
+			{Key: "email_id", Value: "user@example.com"},
+			{Key: "message", Value: "Your OTP is 123456"},
+		}))
+
+		jobs, err := GetScheduledJobs()
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		email, ok := jobs[0].(models.EmailJob)
+		assert.True(t, ok)
+		assert.Equal(t, legacyId.Hex(), email.Id)
+		assert.Equal(t, "user@example.com", email.To)
+		assert.Equal(t, legacyId.Timestamp(), email.SendAt)
+
+		// the email job deletes itself once it ran
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+		assert.NoError(t, DeleteScheduledJob(email))
+
+		var deleted *bson.Raw
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName == "delete" {
+				deleted = &event.Command
+			}
+		}
+		if assert.NotNil(t, deleted) {
+			filter := deleted.Lookup("deletes", "0", "q", "$or", "1")
+			id, ok := filter.Document().Lookup("_id").ObjectIDOK()
+			assert.True(t, ok)
+			assert.Equal(t, legacyId, id, "the delete must match the stored legacy document")
+		}
+	})
+}
+
+func TestGetUnsyncedJobs_PicksUpOutsideUpdates(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("polling", func(mt *mtest.T) {
+		scheduledItemsCollection = mt.Coll
+		syncedAt := primitive.NewDateTimeFromTime(time.Now().Add(-time.Hour))
+		emailDoc := func(id primitive.ObjectID, message string) bson.D {
+			return bson.D{
+				{Key: "_id", Value: id},
+				{Key: "kind", Value: models.JobKindSendEmail},
+				{Key: "key", Value: "email:" + id.Hex()},
+				{Key: syncedAtField, Value: syncedAt},
+				{Key: "id", Value: id.Hex()},
+				{Key: "user_id", Value: "user-1"},
+				{Key: "to", Value: "user@example.com"},
+				{Key: "message", Value: message},
+			}
+		}
+		stamped := func(doc bson.D, seen bson.D) bson.D {
+			raw, err := bson.Marshal(seen)
+			assert.NoError(t, err)
+			hash, err := contentHash(raw)
+			assert.NoError(t, err)
+			return append(doc, bson.E{Key: syncedHashField, Value: hash})
+		}
+
+		// the scheduler wrote it since the last poll
+		ownId := primitive.NewObjectID()
+		own := emailDoc(ownId, "welcome")
+		// an admin script set a new message on a document the watcher already saw
+		updatedId := primitive.NewObjectID()
+		updated := stamped(emailDoc(updatedId, "new message"), emailDoc(updatedId, "old message"))
+		// seen and unchanged
+		sameId := primitive.NewObjectID()
+		same := stamped(emailDoc(sameId, "hello"), emailDoc(sameId, "hello"))
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.scheduled_items", mtest.FirstBatch, own, updated, same),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 2}},
+		)
+
+		changes, err := GetUnsyncedJobs()
+		assert.NoError(t, err)
+		if assert.Len(t, changes, 1) {
+			assert.Equal(t, updatedId.Hex(), changes[0].Id)
+			assert.Equal(t, "new message", changes[0].Job.(models.EmailJob).Message)
+		}
+
+		var stampedIds []primitive.ObjectID
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			if event.CommandName != "update" {
+				continue
+			}
+			values, _ := event.Command.Lookup("updates").Array().Values()
+			for _, value := range values {
+				stampedIds = append(stampedIds, value.Document().Lookup("q", "_id").ObjectID())
+			}
+		}
+		assert.ElementsMatch(t, []primitive.ObjectID{ownId, updatedId}, stampedIds)
+	})
+}
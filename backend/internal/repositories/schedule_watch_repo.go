@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"social-scribe/backend/internal/models"
+)
+
+// Every write the scheduler makes to scheduled_items stamps synced_at. A document
+// without it, or an update that doesn't set it, was written by someone else: an admin
+// script, an import or another service.
+const syncedAtField = "synced_at"
+
+// syncedHashField is the fingerprint of a document when the watcher last saw it. The
+// scheduler's own updates drop it, so when polling a document whose content no longer
+// matches it was changed by someone else since.
+const syncedHashField = "synced_hash"
+
+// changeStreamsUnsupported is the code MongoDB answers with when a change stream is
+// opened on a standalone server.
+const changeStreamsUnsupported = 40573
+
+var ErrChangeStreamsUnsupported = errors.New("change streams need a replica set")
+
+var (
+	// WatchScheduledJobs calls handle with every write other processes make to
+	// scheduled_items until ctx is done or the stream breaks
+	WatchScheduledJobs = defaultWatchScheduledJobs
+	// GetUnsyncedJobs returns the stored jobs the scheduler hasn't seen yet or that others
+	// changed since it did, and stamps them
+	GetUnsyncedJobs = defaultGetUnsyncedJobs
+	// GetStoredJobKeys returns the job key of every stored job by document id
+	GetStoredJobKeys = defaultGetStoredJobKeys
+)
+
+// ScheduledJobChange is a write to scheduled_items. Job is nil when the document was
+// deleted, Runnable is false when the job is stored but must not run, like failed and
+// held tasks.
+type ScheduledJobChange struct {
+	Id       string
+	Job      models.Job
+	Runnable bool
+}
+
+// runnable tells whether a stored job would be loaded at startup, see GetScheduledJobs.
+func runnable(raw bson.Raw) bool {
+	status, _ := raw.Lookup("status").StringValueOK()
+	return status != models.TaskStatusFailed && status != models.TaskStatusHeld
+}
+
+func storedJobChange(raw bson.Raw) (ScheduledJobChange, error) {
+	id, _ := raw.Lookup("_id").ObjectIDOK()
+	job, err := decodeJob(raw)
+	if err != nil {
+		return ScheduledJobChange{Id: id.Hex()}, err
+	}
+	return ScheduledJobChange{Id: id.Hex(), Job: job, Runnable: runnable(raw)}, nil
+}
+
+// contentHash fingerprints a stored document, leaving out its sync stamps.
+func contentHash(raw bson.Raw) (string, error) {
+	elements, err := raw.Elements()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, element := range elements {
+		if key := element.Key(); key == syncedAtField || key == syncedHashField {
+			continue
+		}
+		hash.Write(element)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// markSynced stamps the documents as seen with their fingerprint. A document the
+// scheduler wrote again since it was read keeps its newer stamp.
+func markSynced(ctx context.Context, docs []bson.Raw) error {
+	var updates []mongo.WriteModel
+	for _, doc := range docs {
+		hash, err := contentHash(doc)
+		if err != nil {
+			return err
+		}
+		filter := bson.M{"_id": doc.Lookup("_id")}
+		if syncedAt, err := doc.LookupErr(syncedAtField); err == nil {
+			filter[syncedAtField] = syncedAt
+		} else {
+			filter[syncedAtField] = bson.M{"$exists": false}
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(
+			bson.M{"$set": bson.M{syncedAtField: time.Now(), syncedHashField: hash}},
+		))
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	_, err := scheduledItemsCollection.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+type scheduledItemEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		Id primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.Raw `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.Raw `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+// ours tells whether the scheduler made the write. Its own inserts are still passed on
+// with ours set, so the watcher learns the key of every document it may see deleted.
+func (e scheduledItemEvent) ours() bool {
+	switch e.OperationType {
+	case "update":
+		_, err := e.UpdateDescription.UpdatedFields.LookupErr(syncedAtField)
+		return err == nil
+	case "insert", "replace":
+		_, err := e.FullDocument.LookupErr(syncedAtField)
+		return err == nil
+	}
+	return false
+}
+
+func defaultWatchScheduledJobs(ctx context.Context, handle func(change ScheduledJobChange, ours bool)) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType": bson.M{"$in": []string{"insert", "update", "replace", "delete"}},
+	}}}}
+	stream, err := scheduledItemsCollection.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == changeStreamsUnsupported {
+		return ErrChangeStreamsUnsupported
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error watching scheduled jobs: %v", err)
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event scheduledItemEvent
+		if err := stream.Decode(&event); err != nil {
+			log.Printf("[ERROR] Error decoding scheduled job change, skipping it: %v", err)
+			continue
+		}
+		if event.OperationType == "delete" {
+			handle(ScheduledJobChange{Id: event.DocumentKey.Id.Hex()}, false)
+			continue
+		}
+		// the document of an update is looked up afterwards, it's gone when it was
+		// deleted since and the delete comes next
+		if event.FullDocument == nil {
+			continue
+		}
+		change, err := storedJobChange(event.FullDocument)
+		if err != nil {
+			log.Printf("[ERROR] Error decoding changed scheduled job %s, skipping it: %v", event.DocumentKey.Id.Hex(), err)
+			continue
+		}
+		ours := event.ours()
+		handle(change, ours)
+		if !ours {
+			if err := markSynced(ctx, []bson.Raw{event.FullDocument}); err != nil {
+				log.Printf("[WARN] Error stamping scheduled job %s as synced: %v", change.Id, err)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
+
+func defaultGetUnsyncedJobs() ([]ScheduledJobChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// an outside update can't be told from the query, every document is compared with
+	// its fingerprint
+	cursor, err := scheduledItemsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("[ERROR] Error getting unsynced scheduled jobs: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []ScheduledJobChange
+	var stamp []bson.Raw
+	for cursor.Next(ctx) {
+		doc := cursor.Current
+		_, synced := doc.Lookup(syncedAtField).TimeOK()
+		seenHash, seen := doc.Lookup(syncedHashField).StringValueOK()
+		hash, err := contentHash(doc)
+		if err != nil {
+			log.Printf("[ERROR] Error reading scheduled job, skipping it: %v", err)
+			continue
+		}
+		switch {
+		case synced && !seen:
+			// written by the scheduler since the last poll, only the fingerprint is new
+			stamp = append(stamp, append(bson.Raw(nil), doc...))
+			continue
+		case synced && seenHash == hash:
+			continue
+		}
+		change, err := storedJobChange(doc)
+		if err != nil {
+			log.Printf("[ERROR] Error decoding scheduled job %s, skipping it: %v", change.Id, err)
+			continue
+		}
+		changes = append(changes, change)
+		stamp = append(stamp, append(bson.Raw(nil), doc...))
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("[ERROR] Error reading unsynced scheduled jobs: %v", err)
+		return nil, err
+	}
+
+	if err := markSynced(ctx, stamp); err != nil {
+		log.Printf("[ERROR] Error stamping scheduled jobs as synced: %v", err)
+		return nil, err
+	}
+	return changes, nil
+}
+
+func defaultGetStoredJobKeys() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := scheduledItemsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("[ERROR] Error getting stored scheduled jobs: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := make(map[string]string)
+	for cursor.Next(ctx) {
+		change, err := storedJobChange(cursor.Current)
+		if err != nil {
+			continue
+		}
+		keys[change.Id] = change.Job.Key()
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("[ERROR] Error reading stored scheduled jobs: %v", err)
+		return nil, err
+	}
+	return keys, nil
+}
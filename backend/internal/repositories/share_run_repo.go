@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"social-scribe/backend/internal/models"
+)
+
+var (
+	InsertShareRun = defaultInsertShareRun
+	// GetShareRuns returns a page of the user's share runs, newest first, and how many
+	// runs match the query in total
+	GetShareRuns = defaultGetShareRuns
+)
+
+func createShareRunIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := shareRunsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "blog_id", Value: 1}, {Key: "started_at", Value: -1}}},
+	})
+	return err
+}
+
+func defaultInsertShareRun(run *models.ShareRun) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := shareRunsCollection.InsertOne(ctx, run)
+	if err != nil {
+		log.Printf("[ERROR] Failed to store share run: %v", err)
+		return err
+	}
+	return nil
+}
+
+func defaultGetShareRuns(userId string, query models.ShareRunQuery) ([]models.ShareRun, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userId}
+	if query.BlogId != "" {
+		filter["blog_id"] = query.BlogId
+	}
+	startedAt := bson.M{}
+	if !query.From.IsZero() {
+		startedAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		startedAt["$lt"] = query.To
+	}
+	if len(startedAt) > 0 {
+		filter["started_at"] = startedAt
+	}
+
+	total, err := shareRunsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		log.Printf("[ERROR] Error counting share runs: %v", err)
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((query.Page - 1) * query.Limit)).
+		SetLimit(int64(query.Limit))
+	cursor, err := shareRunsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[ERROR] Error getting share runs: %v", err)
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.ShareRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		log.Printf("[ERROR] Error decoding share runs: %v", err)
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/clock"
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+var agentStart = time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+// newAgentTestScheduler runs the scheduler on a fake clock and records the key of every
+// job the agent hands to a worker.
+func newAgentTestScheduler(t *testing.T) (*Scheduler, *clock.Fake, chan string) {
+	repo.GetScheduledJobs = func() ([]models.Job, error) {
+		return []models.Job{}, nil
+	}
+	repo.StoreScheduledJob = func(job models.Job) error {
+		return nil
+	}
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		return nil
+	}
+	fake := clock.NewFake(agentStart)
+	s := newScheduler(newMemoryQueue(), 0, poolConfig{Workers: 1}, fake)
+	t.Cleanup(s.Stop)
+
+	ran := make(chan string, 10)
+	s.RegisterHandler(models.JobKindShareBlog, func(job models.Job) error {
+		ran <- job.Key()
+		return nil
+	})
+	return s, fake, ran
+}
+
+// advanceTo waits for the agent to sleep until at, then moves the clock there.
+func advanceTo(t *testing.T, fake *clock.Fake, at time.Time) {
+	t.Helper()
+	if !fake.WaitForTimer(at, time.Second) {
+		t.Fatalf("agent never armed a timer for %s, armed: %v", at, fake.Armed())
+	}
+	fake.Set(at)
+}
+
+func expectRun(t *testing.T, ran chan string, key string) {
+	t.Helper()
+	select {
+	case got := <-ran:
+		assert.Equal(t, key, got)
+	case <-time.After(time.Second):
+		t.Fatalf("job %s didn't run", key)
+	}
+}
+
+func expectNoRun(t *testing.T, ran chan string) {
+	t.Helper()
+	select {
+	case got := <-ran:
+		t.Fatalf("job %s ran early", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestAgent_RunsJobsInOrder(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	first, second, third := agentStart.Add(time.Minute), agentStart.Add(2*time.Minute), agentStart.Add(3*time.Minute)
+	assert.NoError(t, s.AddJob(testTask("user1", "blog3", third)))
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", first)))
+	assert.NoError(t, s.AddJob(testTask("user1", "blog2", second)))
+
+	assert.True(t, fake.WaitForTimer(first, time.Second))
+	fake.Set(first.Add(-time.Second))
+	expectNoRun(t, ran)
+	advanceTo(t, fake, first)
+	expectRun(t, ran, "blog1")
+	advanceTo(t, fake, second)
+	expectRun(t, ran, "blog2")
+	advanceTo(t, fake, third)
+	expectRun(t, ran, "blog3")
+}
+
+func TestAgent_DueJobsRunTogether(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	at := agentStart.Add(time.Minute)
+	assert.NoError(t, s.AddJob(testTask("user1", "blog2", at.Add(time.Second))))
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", at)))
+
+	// a single advance past both run times runs them in run time order
+	assert.True(t, fake.WaitForTimer(at, time.Second))
+	fake.Set(at.Add(time.Hour))
+	expectRun(t, ran, "blog1")
+	expectRun(t, ran, "blog2")
+}
+
+func TestAgent_CancelledJobDoesNotRun(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	at := agentStart.Add(time.Minute)
+	blog := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at})
+	assert.NoError(t, s.AddScheduledBlog("user1", blog))
+	assert.NoError(t, s.AddJob(testTask("user1", "blog2", at.Add(time.Minute))))
+
+	assert.True(t, fake.WaitForTimer(at, time.Second))
+	assert.NoError(t, s.RemoveTask(blog))
+
+	// the agent goes back to sleep until the job that is left
+	advanceTo(t, fake, at.Add(time.Minute))
+	expectRun(t, ran, "blog2")
+	expectNoRun(t, ran)
+}
+
+func TestAgent_RetimedJobRunsAtNewTime(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	repo.SaveRescheduledBlog = func(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, removed []models.Job) error {
+		return nil
+	}
+	at := agentStart.Add(time.Hour)
+	old := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at})
+	assert.NoError(t, s.AddScheduledBlog("user1", old))
+	assert.True(t, fake.WaitForTimer(at, time.Second))
+
+	later := at.Add(time.Hour)
+	assert.NoError(t, s.RescheduleBlog("user1", old, scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: later})))
+
+	advanceTo(t, fake, later)
+	expectRun(t, ran, models.ShareJobKey("blog1", "twitter"))
+
+	// moving a job earlier wakes the agent too
+	earlier := later.Add(time.Minute)
+	blog := scheduledBlog("blog2", models.PlatformTarget{Platform: "twitter", ScheduledTime: later.Add(time.Hour)})
+	assert.NoError(t, s.AddScheduledBlog("user1", blog))
+	assert.True(t, fake.WaitForTimer(later.Add(time.Hour), time.Second))
+	assert.NoError(t, s.RescheduleBlog("user1", blog, scheduledBlog("blog2", models.PlatformTarget{Platform: "twitter", ScheduledTime: earlier})))
+
+	advanceTo(t, fake, earlier)
+	expectRun(t, ran, models.ShareJobKey("blog2", "twitter"))
+}
+
+func TestAgent_EarlierJobAddedWhileTimerArmed(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	late := agentStart.Add(time.Hour)
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", late)))
+	assert.True(t, fake.WaitForTimer(late, time.Second))
+
+	early := agentStart.Add(time.Minute)
+	assert.NoError(t, s.AddJob(testTask("user1", "blog2", early)))
+
+	advanceTo(t, fake, early)
+	expectRun(t, ran, "blog2")
+	advanceTo(t, fake, late)
+	expectRun(t, ran, "blog1")
+}
+
+func TestAgent_LaterJobAddedWhileTimerArmed(t *testing.T) {
+	s, fake, ran := newAgentTestScheduler(t)
+	early := agentStart.Add(time.Minute)
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", early)))
+	assert.True(t, fake.WaitForTimer(early, time.Second))
+
+	assert.NoError(t, s.AddJob(testTask("user1", "blog2", agentStart.Add(time.Hour))))
+
+	// the new job doesn't push the armed timer back
+	advanceTo(t, fake, early)
+	expectRun(t, ran, "blog1")
+	expectNoRun(t, ran)
+}
+
+func TestAgent_EmailJobFollowsClock(t *testing.T) {
+	s, fake, _ := newAgentTestScheduler(t)
+	sent := make(chan string, 1)
+	s.RegisterHandler(models.JobKindSendEmail, func(job models.Job) error {
+		sent <- job.(models.EmailJob).To
+		return nil
+	})
+
+	at := s.Clock().Now().Add(time.Minute)
+	assert.NoError(t, s.AddJob(models.NewEmailJob("user1", "someone@example.com", "hello", at)))
+	expectNoRun(t, sent)
+	advanceTo(t, fake, at)
+	expectRun(t, sent, "someone@example.com")
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+// publishingRules returns the user's publishing rules, in the user's timezone unless
+// the rules have their own.
+func publishingRules(user *models.User) *models.PublishingRules {
+	if user.PublishingRules == nil {
+		return nil
+	}
+	rules := *user.PublishingRules
+	if rules.Timezone == "" {
+		rules.Timezone = user.Timezone
+	}
+	return &rules
+}
+
+// holdBackShare keeps a share that is due in quiet hours or a blackout from going out.
+// It is deferred to the next allowed time, or held until the user releases it when the
+// rules say so or there is no allowed time.
+func (s *Scheduler) holdBackShare(task models.ScheduledBlogData, user *models.User, action string, allowedAt time.Time, reason string) {
+	blogId := task.ScheduledBlog.Blog.Id
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+
+	if action == models.BlackoutDefer && !allowedAt.IsZero() {
+		log.Printf("[INFO] Deferring blog with ID %s for user ID %s to %s, %s", blogId, task.UserID, allowedAt.Format(time.RFC3339), reason)
+		task.NextAttemptAt = allowedAt
+		if err := repo.UpdateScheduledTask(task); err != nil {
+			log.Printf("[ERROR] Error updating scheduled task for blog id %s: %v", blogId, err)
+		}
+		s.requeue(task)
+		if index >= 0 {
+			updateTargets(&user.ScheduledBlogs[index], task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+				target.Result = fmt.Sprintf("deferred to %s, %s", allowedAt.Format(time.RFC3339), reason)
+			})
+			if err := repo.UpdateUser(task.UserID, user); err != nil {
+				log.Printf("[ERROR] Error updating user: %v", err)
+			}
+		}
+		return
+	}
+
+	log.Printf("[INFO] Holding blog with ID %s for user ID %s for review, %s", blogId, task.UserID, reason)
+	task.Status = models.TaskStatusHeld
+	if err := repo.UpdateScheduledTask(task); err != nil {
+		log.Printf("[ERROR] Error updating scheduled task for blog id %s: %v", blogId, err)
+	}
+	if index >= 0 {
+		scheduled := &user.ScheduledBlogs[index]
+		// blogs scheduled before targets existed are held as a whole
+		if len(scheduled.Targets) == 0 {
+			scheduled.Status = models.TaskStatusHeld
+		}
+		updateTargets(scheduled, task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+			target.Status = models.TaskStatusHeld
+			target.Result = fmt.Sprintf("held for review, %s", reason)
+		})
+	}
+	user.Notifications = append(user.Notifications, fmt.Sprintf("The scheduled blog \"%s\" was due during %s and is held until you release it", task.ScheduledBlog.Title, reason))
+	if err := repo.UpdateUser(task.UserID, user); err != nil {
+		log.Printf("[ERROR] Error updating user: %v", err)
+	}
+}
+
+// ReleaseHeld puts the held targets of a scheduled blog back on the queue to go out
+// right away, whatever the publishing rules say. It returns the released platforms,
+// updating the user's scheduled blog is left to the caller.
+func (s *Scheduler) ReleaseHeld(userId string, blog models.ScheduledBlog) ([]string, error) {
+	var tasks []models.ScheduledBlogData
+	if len(blog.Targets) == 0 && blog.Status == models.TaskStatusHeld {
+		task := models.ScheduledBlogData{UserID: userId, ScheduledBlog: blog, MaxAttempts: defaultMaxAttempts}
+		task.ScheduledBlog.Status = ""
+		tasks = append(tasks, task)
+	}
+	for _, target := range blog.Targets {
+		if target.Status == models.TaskStatusHeld {
+			target.Status = ""
+			tasks = append(tasks, targetTask(userId, blog, target))
+		}
+	}
+
+	var released []string
+	for _, task := range tasks {
+		task.Status = models.TaskStatusPending
+		task.Approved = true
+		task.NextAttemptAt = s.clock.Now()
+		if err := repo.UpdateScheduledTask(task); err != nil {
+			log.Printf("[ERROR] Error releasing held task %s: %v", task.Key(), err)
+			return released, err
+		}
+		if err := s.queue.Push(task); err != nil {
+			log.Printf("[ERROR] Error adding task to the queue: %v", err)
+			return released, err
+		}
+		released = append(released, task.ScheduledBlog.Platforms...)
+	}
+	s.notify()
+	return released, nil
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+// blackoutToday blocks the whole of today in UTC.
+func blackoutToday(action string) *models.PublishingRules {
+	today := time.Now().UTC().Format("2006-01-02")
+	return &models.PublishingRules{Action: action, Blackouts: []models.BlackoutDate{{From: today, Reason: "freeze"}}}
+}
+
+func TestRunShareTask_DefersDuringBlackout(t *testing.T) {
+	s := newTestScheduler(t)
+	blog := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: time.Now()})
+	user := &models.User{ScheduledBlogs: []models.ScheduledBlog{blog}, PublishingRules: blackoutToday(models.BlackoutDefer)}
+	repo.GetUserById = func(userId string) (*models.User, error) {
+		return user, nil
+	}
+	repo.UpdateUser = func(userId string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	var updated []models.ScheduledBlogData
+	repo.UpdateScheduledTask = func(task models.ScheduledBlogData) error {
+		updated = append(updated, task)
+		return nil
+	}
+
+	s.runShareTask(targetTask("user1", blog, blog.Targets[0]))
+
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	assert.Len(t, updated, 1)
+	assert.Equal(t, tomorrow, updated[0].NextAttemptAt)
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, tomorrow, pending[0].RunAt())
+	assert.Contains(t, user.ScheduledBlogs[0].Targets[0].Result, "blackout: freeze")
+}
+
+func TestRunShareTask_HoldsForReviewAndReleases(t *testing.T) {
+	s := newTestScheduler(t)
+	blog := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: time.Now()})
+	user := &models.User{ScheduledBlogs: []models.ScheduledBlog{blog}, PublishingRules: blackoutToday(models.BlackoutHold)}
+	repo.GetUserById = func(userId string) (*models.User, error) {
+		return user, nil
+	}
+	repo.UpdateUser = func(userId string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	var updated []models.ScheduledBlogData
+	repo.UpdateScheduledTask = func(task models.ScheduledBlogData) error {
+		updated = append(updated, task)
+		return nil
+	}
+
+	s.runShareTask(targetTask("user1", blog, blog.Targets[0]))
+
+	assert.Len(t, updated, 1)
+	assert.Equal(t, models.TaskStatusHeld, updated[0].Status)
+	assert.Equal(t, models.TaskStatusHeld, user.ScheduledBlogs[0].Targets[0].Status)
+	assert.Len(t, user.Notifications, 1)
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	ran := make(chan models.Job, 1)
+	s.RegisterHandler(models.JobKindShareBlog, func(job models.Job) error {
+		ran <- job
+		return nil
+	})
+	released, err := s.ReleaseHeld("user1", user.ScheduledBlogs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"twitter"}, released)
+	select {
+	case job := <-ran:
+		assert.True(t, job.(models.ScheduledBlogData).Approved)
+		assert.Equal(t, models.TaskStatusPending, job.(models.ScheduledBlogData).Status)
+	case <-time.After(2 * time.Second):
+		t.Fatal("released share didn't run")
+	}
+}
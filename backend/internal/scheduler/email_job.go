@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+	"social-scribe/backend/internal/services"
+)
+
+// runEmailJob is the handler of send_email jobs. Emails are sent once, a failed email
+// is logged and dropped like before.
+func runEmailJob(job models.Job) error {
+	email, ok := job.(models.EmailJob)
+	if !ok {
+		return fmt.Errorf("unexpected %T payload for a %s job", job, models.JobKindSendEmail)
+	}
+
+	sendErr := services.SendEmail(email.To, email.Message)
+	if err := repo.DeleteScheduledJob(email); err != nil {
+		log.Printf("[ERROR] Error deleting scheduled job: %v", err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("sending email to %s: %v", email.To, sendErr)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	defaultWorkers             = 8
+	defaultPlatformConcurrency = 4
+	defaultUserConcurrency     = 2
+	// waitingPerWorker bounds the jobs waiting on a platform or user cap, past it the
+	// agent stops claiming so their claims don't expire in line
+	waitingPerWorker = 4
+)
+
+// poolConfig bounds how much work runs at once. Every platform and every user gets the
+// same cap, zero means no cap.
+type poolConfig struct {
+	Workers             int
+	PlatformConcurrency int
+	UserConcurrency     int
+}
+
+func poolConfigFromEnv() poolConfig {
+	return poolConfig{
+		Workers:             envInt("SCHEDULER_WORKERS", defaultWorkers),
+		PlatformConcurrency: envInt("SCHEDULER_PLATFORM_CONCURRENCY", defaultPlatformConcurrency),
+		UserConcurrency:     envInt("SCHEDULER_USER_CONCURRENCY", defaultUserConcurrency),
+	}
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return fallback
+	}
+	return value
+}
+
+// workerPool runs claimed jobs on a fixed number of workers. A job whose platform or
+// user is at its cap waits in line until a running job of the same platform or user
+// finishes, jobs behind it that fit go first.
+type workerPool struct {
+	config poolConfig
+	run    func(job models.Job)
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	waiting    []models.Job
+	running    int
+	byPlatform map[string]int
+	byUser     map[string]int
+	active     map[string]models.Job
+	closed     bool
+
+	// freed is signalled whenever a worker finishes a job
+	freed chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newWorkerPool(config poolConfig, run func(job models.Job)) *workerPool {
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+	p := &workerPool{
+		config:     config,
+		run:        run,
+		byPlatform: make(map[string]int),
+		byUser:     make(map[string]int),
+		active:     make(map[string]models.Job),
+		freed:      make(chan struct{}, 1),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit puts a job in line, it never blocks. It returns false when the pool is
+// closed, the job is left to the caller then.
+func (p *workerPool) Submit(job models.Job) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.waiting = append(p.waiting, job)
+	p.cond.Signal()
+	return true
+}
+
+// Free returns how many more jobs the pool can take without them piling up, the agent
+// only claims that many so claimed jobs don't sit around while their claim expires.
+// Jobs waiting on a platform or user cap don't take a worker, so they only count
+// against the bound of the line and not against the free workers.
+func (p *workerPool) Free() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	free := min(p.config.Workers-p.running-p.runnable(), p.config.Workers*waitingPerWorker-len(p.waiting))
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// runnable counts the waiting jobs that get a worker as soon as one is free, taking
+// them in line the way next does, p.mu must be held.
+func (p *workerPool) runnable() int {
+	byUser := make(map[string]int)
+	byPlatform := make(map[string]int)
+	count := 0
+	for _, job := range p.waiting {
+		if p.config.UserConcurrency > 0 && p.byUser[job.Owner()]+byUser[job.Owner()] >= p.config.UserConcurrency {
+			continue
+		}
+		fits := true
+		if p.config.PlatformConcurrency > 0 {
+			for _, platform := range jobPlatforms(job) {
+				if p.byPlatform[platform]+byPlatform[platform] >= p.config.PlatformConcurrency {
+					fits = false
+				}
+			}
+		}
+		if !fits {
+			continue
+		}
+		byUser[job.Owner()]++
+		for _, platform := range jobPlatforms(job) {
+			byPlatform[platform]++
+		}
+		count++
+	}
+	return count
+}
+
+// Stats returns how many jobs are running and how many wait for a worker.
+func (p *workerPool) Stats() (running, waiting int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running, len(p.waiting)
+}
+
+// Freed is signalled when a worker finishes a job.
+func (p *workerPool) Freed() <-chan struct{} {
+	return p.freed
+}
+
+// Close stops the workers once their current job is done, jobs still waiting in line
+// are returned so the caller can put them back.
+func (p *workerPool) Close() []models.Job {
+	p.mu.Lock()
+	p.closed = true
+	waiting := p.waiting
+	p.waiting = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	return waiting
+}
+
+// Wait blocks until the workers returned after Close. When ctx is done first the jobs
+// that are still running are returned.
+func (p *workerPool) Wait(ctx context.Context) []models.Job {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		running := make([]models.Job, 0, len(p.active))
+		for _, job := range p.active {
+			running = append(running, job)
+		}
+		return running
+	}
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		job, ok := p.next()
+		for !ok && !p.closed {
+			p.cond.Wait()
+			job, ok = p.next()
+		}
+		if !ok {
+			p.mu.Unlock()
+			return
+		}
+		p.acquire(job, 1)
+		p.mu.Unlock()
+
+		p.run(job)
+
+		p.mu.Lock()
+		p.acquire(job, -1)
+		// a finished job may unblock waiting jobs of the same platform or user
+		p.cond.Broadcast()
+		p.mu.Unlock()
+		select {
+		case p.freed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Has tells whether the job is waiting for a worker or running.
+func (p *workerPool) Has(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.active[key]; ok {
+		return true
+	}
+	for _, job := range p.waiting {
+		if job.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// next takes the first waiting job that fits the caps off the line, p.mu must be held.
+func (p *workerPool) next() (models.Job, bool) {
+	for i, job := range p.waiting {
+		if p.fits(job) {
+			p.waiting = append(p.waiting[:i], p.waiting[i+1:]...)
+			return job, true
+		}
+	}
+	return nil, false
+}
+
+func (p *workerPool) fits(job models.Job) bool {
+	if p.config.UserConcurrency > 0 && p.byUser[job.Owner()] >= p.config.UserConcurrency {
+		return false
+	}
+	if p.config.PlatformConcurrency > 0 {
+		for _, platform := range jobPlatforms(job) {
+			if p.byPlatform[platform] >= p.config.PlatformConcurrency {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *workerPool) acquire(job models.Job, delta int) {
+	p.running += delta
+	if delta > 0 {
+		p.active[job.Key()] = job
+	} else {
+		delete(p.active, job.Key())
+	}
+	p.byUser[job.Owner()] += delta
+	if p.byUser[job.Owner()] == 0 {
+		delete(p.byUser, job.Owner())
+	}
+	for _, platform := range jobPlatforms(job) {
+		p.byPlatform[platform] += delta
+		if p.byPlatform[platform] == 0 {
+			delete(p.byPlatform, platform)
+		}
+	}
+}
+
+// jobPlatforms returns the platforms a job posts to, jobs that don't post anywhere
+// only count against the worker and user caps.
+func jobPlatforms(job models.Job) []string {
+	if task, ok := job.(models.ScheduledBlogData); ok {
+		return task.ScheduledBlog.Platforms
+	}
+	return nil
+}
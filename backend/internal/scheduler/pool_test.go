@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/models"
+)
+
+// blockingRun records the most jobs it ran at once per platform and per user, every
+// job blocks until release is closed.
+type blockingRun struct {
+	mu          sync.Mutex
+	byPlatform  map[string]int
+	byUser      map[string]int
+	maxPlatform map[string]int
+	maxUser     map[string]int
+	done        sync.WaitGroup
+	release     chan struct{}
+}
+
+func newBlockingRun(jobs int) *blockingRun {
+	r := &blockingRun{
+		byPlatform:  map[string]int{},
+		byUser:      map[string]int{},
+		maxPlatform: map[string]int{},
+		maxUser:     map[string]int{},
+		release:     make(chan struct{}),
+	}
+	r.done.Add(jobs)
+	return r
+}
+
+func (r *blockingRun) run(job models.Job) {
+	defer r.done.Done()
+	platform := jobPlatforms(job)[0]
+
+	r.mu.Lock()
+	r.byPlatform[platform]++
+	r.byUser[job.Owner()]++
+	r.maxPlatform[platform] = max(r.maxPlatform[platform], r.byPlatform[platform])
+	r.maxUser[job.Owner()] = max(r.maxUser[job.Owner()], r.byUser[job.Owner()])
+	r.mu.Unlock()
+
+	<-r.release
+
+	r.mu.Lock()
+	r.byPlatform[platform]--
+	r.byUser[job.Owner()]--
+	r.mu.Unlock()
+}
+
+func platformTask(userId, blogId, platform string) models.ScheduledBlogData {
+	task := testTask(userId, blogId, time.Now())
+	task.Platform = platform
+	task.ScheduledBlog.Platforms = []string{platform}
+	return task
+}
+
+func TestWorkerPool_RespectsCaps(t *testing.T) {
+	jobs := []models.Job{
+		platformTask("user1", "blog1", "twitter"),
+		platformTask("user1", "blog2", "twitter"),
+		platformTask("user1", "blog3", "linkedin"),
+		platformTask("user2", "blog4", "twitter"),
+		platformTask("user3", "blog5", "twitter"),
+		platformTask("user4", "blog6", "linkedin"),
+	}
+	run := newBlockingRun(len(jobs))
+	pool := newWorkerPool(poolConfig{Workers: 4, PlatformConcurrency: 2, UserConcurrency: 1}, run.run)
+	defer pool.Close()
+
+	for _, job := range jobs {
+		pool.Submit(job)
+	}
+	// three jobs fit the caps, the other three wait on them and leave a worker free
+	assert.Equal(t, 1, pool.Free())
+
+	// let the first wave start before releasing everything
+	time.Sleep(50 * time.Millisecond)
+	close(run.release)
+	run.done.Wait()
+
+	assert.Equal(t, 2, run.maxPlatform["twitter"])
+	assert.LessOrEqual(t, run.maxPlatform["linkedin"], 2)
+	for user, most := range run.maxUser {
+		assert.Equal(t, 1, most, user)
+	}
+}
+
+func TestWorkerPool_CappedJobsDontBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ran := make(chan string, 4)
+	pool := newWorkerPool(poolConfig{Workers: 2, UserConcurrency: 1}, func(job models.Job) {
+		ran <- job.Key()
+		if job.Owner() == "userA" {
+			<-release
+		}
+	})
+	defer pool.Close()
+
+	// user A's burst, one runs and the rest waits on the user cap
+	pool.Submit(platformTask("userA", "blog1", "twitter"))
+	pool.Submit(platformTask("userA", "blog2", "twitter"))
+	pool.Submit(platformTask("userA", "blog3", "twitter"))
+	assert.Equal(t, "blog1:twitter", <-ran)
+	assert.Equal(t, 1, pool.Free(), "user A's capped jobs must leave the idle worker free")
+
+	pool.Submit(platformTask("userB", "blog4", "twitter"))
+	select {
+	case key := <-ran:
+		assert.Equal(t, "blog4:twitter", key)
+	case <-time.After(time.Second):
+		t.Fatal("user B's job waited behind user A's")
+	}
+}
+
+func TestWorkerPool_BoundsCappedJobs(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := newWorkerPool(poolConfig{Workers: 2, UserConcurrency: 1}, func(job models.Job) {
+		<-release
+	})
+	defer pool.Close()
+
+	// a worker is idle but the line of capped jobs is full
+	for i := 0; i < 2*waitingPerWorker+1; i++ {
+		pool.Submit(platformTask("userA", fmt.Sprintf("blog%d", i), "twitter"))
+	}
+	assert.Equal(t, 0, pool.Free())
+}
+
+func TestWorkerPool_QueuedJobsWait(t *testing.T) {
+	ran := make(chan string, 3)
+	release := make(chan struct{})
+	pool := newWorkerPool(poolConfig{Workers: 1}, func(job models.Job) {
+		<-release
+		ran <- job.Key()
+	})
+	defer pool.Close()
+
+	pool.Submit(platformTask("user1", "blog1", "twitter"))
+	pool.Submit(platformTask("user2", "blog2", "twitter"))
+	pool.Submit(platformTask("user3", "blog3", "twitter"))
+
+	close(release)
+	for _, want := range []string{"blog1:twitter", "blog2:twitter", "blog3:twitter"} {
+		select {
+		case key := <-ran:
+			assert.Equal(t, want, key)
+		case <-time.After(time.Second):
+			t.Fatalf("job %s never ran", want)
+		}
+	}
+}
+
+func TestWorkerPool_CloseReturnsWaiting(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := newWorkerPool(poolConfig{Workers: 1}, func(job models.Job) {
+		<-release
+	})
+
+	pool.Submit(platformTask("user1", "blog1", "twitter"))
+	time.Sleep(20 * time.Millisecond)
+	pool.Submit(platformTask("user2", "blog2", "twitter"))
+
+	waiting := pool.Close()
+	assert.Len(t, waiting, 1)
+	assert.Equal(t, "blog2:twitter", waiting[0].Key())
+}
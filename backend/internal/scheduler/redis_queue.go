@@ -0,0 +1,394 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	redisQueueKey      = "scheduler:queue"
+	redisProcessingKey = "scheduler:processing"
+	redisPayloadKey    = "scheduler:tasks"
+	redisLeaseKey      = "scheduler:leases"
+	redisDoneKey       = "scheduler:done"
+
+	redisPollInterval             = 5 * time.Second
+	defaultRedisVisibilityTimeout = 15 * time.Minute
+	redisClaimBatch               = 50
+	// a finished task is remembered this long so a replica that read it from the store
+	// before it finished can't load it again
+	redisDoneTTL = time.Hour
+)
+
+// the queue sorted set scores pending tasks by their run time, the processing set
+// scores claimed tasks by the time their claim expires, and the payload hash holds
+// the task itself. The lease hash holds the token of the current claim of a task and
+// the done set scores finished tasks by when they finished. Every script is given all
+// the keys so they stay in sync.
+var (
+	// KEYS: queue, processing, payloads, leases, done - ARGV: key, score, payload
+	redisLoadScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[3], ARGV[1]) == 1 or redis.call('ZSCORE', KEYS[5], ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return 1
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: key, score, payload
+	redisPushScript = redis.NewScript(`
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[5], ARGV[1])
+return 1
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: key
+	redisRemoveScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return false
+end
+local payload = redis.call('HGET', KEYS[3], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return payload
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: key, score, payload
+	// only a task that is still waiting in the queue is replaced
+	redisUpdateScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return false
+end
+local previous = redis.call('HGET', KEYS[3], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+return previous
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: now, claim expiry, batch
+	// size, lease prefix
+	// claims whose visibility timeout passed go back to the queue first, their worker
+	// is assumed to be dead and its lease is dropped. Every claim gets a lease token,
+	// the prefix and the key, and the claimed tasks come back as key, payload pairs.
+	redisClaimScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, key in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], key)
+	redis.call('HDEL', KEYS[4], key)
+	redis.call('ZADD', KEYS[1], ARGV[1], key)
+end
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+local claimed = {}
+for _, key in ipairs(due) do
+	redis.call('ZREM', KEYS[1], key)
+	local payload = redis.call('HGET', KEYS[3], key)
+	if payload then
+		redis.call('ZADD', KEYS[2], ARGV[2], key)
+		redis.call('HSET', KEYS[4], key, ARGV[4] .. key)
+		table.insert(claimed, key)
+		table.insert(claimed, payload)
+	end
+end
+return claimed
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: claim expiry, then key
+	// and lease token pairs
+	// only claims still held under the same lease are extended
+	redisRenewScript = redis.NewScript(`
+for i = 2, #ARGV, 2 do
+	if redis.call('HGET', KEYS[4], ARGV[i]) == ARGV[i + 1] and redis.call('ZSCORE', KEYS[2], ARGV[i]) then
+		redis.call('ZADD', KEYS[2], ARGV[1], ARGV[i])
+	end
+end
+return 1
+`)
+
+	// KEYS: queue, processing, payloads, leases, done - ARGV: key, lease token, now,
+	// tombstones older than this
+	// a worker whose lease was taken over leaves the task to the new claim, a task the
+	// worker pushed back (retry or next occurrence) keeps its payload
+	redisDoneScript = redis.NewScript(`
+if redis.call('HGET', KEYS[4], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	redis.call('HDEL', KEYS[3], ARGV[1])
+	redis.call('ZADD', KEYS[5], ARGV[3], ARGV[1])
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[5], '-inf', ARGV[4])
+return 1
+`)
+)
+
+// redisQueue is a delayed queue on a Redis sorted set that can be shared by several
+// backend replicas, a task is handed to exactly one of them through an atomic claim.
+// The claims a replica holds are renewed until their job is done, so a job that runs
+// or waits longer than the visibility timeout isn't handed out again.
+type redisQueue struct {
+	client            *redis.Client
+	visibilityTimeout time.Duration
+
+	mu       sync.Mutex
+	leases   map[string]string
+	renewing bool
+}
+
+func newRedisQueue(client *redis.Client, visibilityTimeout time.Duration) *redisQueue {
+	return &redisQueue{
+		client:            client,
+		visibilityTimeout: visibilityTimeout,
+		leases:            make(map[string]string),
+	}
+}
+
+func redisVisibilityTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("SCHEDULER_VISIBILITY_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return defaultRedisVisibilityTimeout
+	}
+	return timeout
+}
+
+func (q *redisQueue) keys() []string {
+	return []string{redisQueueKey, redisProcessingKey, redisPayloadKey, redisLeaseKey, redisDoneKey}
+}
+
+// redisJob is the payload stored in the hash, the kind tells which job type to decode.
+type redisJob struct {
+	Kind string          `json:"kind"`
+	Job  json.RawMessage `json:"job"`
+}
+
+func encodeJob(job models.Job) ([]byte, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(redisJob{Kind: job.Kind(), Job: payload})
+}
+
+// decodeJob reads a stored payload, payloads pushed before jobs had a kind are the
+// blog task itself.
+func decodeJob(payload string) (models.Job, error) {
+	var stored redisJob
+	if err := json.Unmarshal([]byte(payload), &stored); err != nil {
+		return nil, err
+	}
+	data := []byte(stored.Job)
+	if stored.Kind == "" {
+		data = []byte(payload)
+	}
+	return models.DecodeJob(stored.Kind, func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	})
+}
+
+func (q *redisQueue) Load(jobs []models.Job) error {
+	ctx := context.Background()
+
+	for _, job := range jobs {
+		payload, err := encodeJob(job)
+		if err != nil {
+			return err
+		}
+		err = redisLoadScript.Run(ctx, q.client, q.keys(), job.Key(), job.RunAt().UnixMilli(), payload).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *redisQueue) Push(job models.Job) error {
+	ctx := context.Background()
+
+	payload, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	return redisPushScript.Run(ctx, q.client, q.keys(), job.Key(), job.RunAt().UnixMilli(), payload).Err()
+}
+
+func (q *redisQueue) Remove(key string) (models.Job, error) {
+	ctx := context.Background()
+
+	payload, err := redisRemoveScript.Run(ctx, q.client, q.keys(), key).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(payload)
+}
+
+func (q *redisQueue) Update(job models.Job) (models.Job, error) {
+	ctx := context.Background()
+
+	payload, err := encodeJob(job)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := redisUpdateScript.Run(ctx, q.client, q.keys(), job.Key(), job.RunAt().UnixMilli(), payload).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeJob(previous)
+}
+
+func (q *redisQueue) NextRunAt() (time.Time, bool, error) {
+	ctx := context.Background()
+
+	next, err := q.client.ZRangeWithScores(ctx, redisQueueKey, 0, 0).Result()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(next) == 0 {
+		return time.Time{}, false, nil
+	}
+	return time.UnixMilli(int64(next[0].Score)), true, nil
+}
+
+func (q *redisQueue) ClaimDue(now time.Time, limit int) ([]models.Job, error) {
+	ctx := context.Background()
+
+	if limit > redisClaimBatch {
+		limit = redisClaimBatch
+	}
+	prefix, err := leasePrefix()
+	if err != nil {
+		return nil, err
+	}
+	claimExpiry := now.Add(q.visibilityTimeout).UnixMilli()
+	claimed, err := redisClaimScript.Run(ctx, q.client, q.keys(), now.UnixMilli(), claimExpiry, limit, prefix).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	jobs := make([]models.Job, 0, len(claimed)/2)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := 0; i+1 < len(claimed); i += 2 {
+		key, payload := claimed[i], claimed[i+1]
+		// the lease is held even when the payload is broken, Done releases it
+		q.leases[key] = prefix + key
+		job, err := decodeJob(payload)
+		if err != nil {
+			log.Printf("[ERROR] Error decoding claimed job: %v", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	if len(q.leases) > 0 && !q.renewing {
+		q.renewing = true
+		go q.renewLeases()
+	}
+	return jobs, nil
+}
+
+// leasePrefix is a random prefix for the lease tokens of a claim.
+func leasePrefix() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw) + ":", nil
+}
+
+// renewLeases extends the claims this replica holds until none are left.
+func (q *redisQueue) renewLeases() {
+	ticker := time.NewTicker(max(q.visibilityTimeout/3, time.Millisecond))
+	defer ticker.Stop()
+	for range ticker.C {
+		q.mu.Lock()
+		if len(q.leases) == 0 {
+			q.renewing = false
+			q.mu.Unlock()
+			return
+		}
+		args := []interface{}{time.Now().Add(q.visibilityTimeout).UnixMilli()}
+		for key, token := range q.leases {
+			args = append(args, key, token)
+		}
+		q.mu.Unlock()
+
+		err := redisRenewScript.Run(context.Background(), q.client, q.keys(), args...).Err()
+		if errors.Is(err, redis.ErrClosed) {
+			q.mu.Lock()
+			q.renewing = false
+			q.mu.Unlock()
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error renewing job claims: %v", err)
+		}
+	}
+}
+
+func (q *redisQueue) Done(job models.Job) error {
+	ctx := context.Background()
+
+	q.mu.Lock()
+	token, ok := q.leases[job.Key()]
+	delete(q.leases, job.Key())
+	q.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	released, err := redisDoneScript.Run(ctx, q.client, q.keys(), job.Key(), token, now.UnixMilli(), now.Add(-redisDoneTTL).UnixMilli()).Int()
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		log.Printf("[WARN] Claim on job %s expired before it was done, it was handed out again", job.Key())
+	}
+	return nil
+}
+
+func (q *redisQueue) Pending() ([]models.Job, error) {
+	ctx := context.Background()
+
+	keys, err := q.client.ZRange(ctx, redisQueueKey, 0, -1).Result()
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	payloads, err := q.client.HMGet(ctx, redisPayloadKey, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]models.Job, 0, len(payloads))
+	for _, payload := range payloads {
+		text, ok := payload.(string)
+		if !ok {
+			continue
+		}
+		job, err := decodeJob(text)
+		if err != nil {
+			log.Printf("[ERROR] Error decoding queued job: %v", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
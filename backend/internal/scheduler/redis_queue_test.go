@@ -0,0 +1,232 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/models"
+)
+
+func newTestRedisQueue(t *testing.T, visibilityTimeout time.Duration) (*redisQueue, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return newRedisQueue(client, visibilityTimeout), server
+}
+
+func testTask(userId, blogId string, at time.Time) models.ScheduledBlogData {
+	task := models.ScheduledBlogData{UserID: userId}
+	task.ScheduledBlog.Id = blogId
+	task.ScheduledBlog.ScheduledTime = at
+	task.ScheduledBlog.Platforms = []string{"twitter"}
+	return task
+}
+
+func TestRedisQueue_ClaimDueOnce(t *testing.T) {
+	queue, _ := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now.Add(-time.Second))))
+	assert.NoError(t, queue.Push(testTask("user1", "blog2", now.Add(time.Hour))))
+
+	next, ok, err := queue.NextRunAt()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.WithinDuration(t, now.Add(-time.Second), next, time.Millisecond)
+
+	claimed, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, "blog1", claimed[0].(models.ScheduledBlogData).ScheduledBlog.Id)
+
+	// a second replica claiming at the same time gets nothing
+	claimed, err = queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestRedisQueue_VisibilityTimeout(t *testing.T) {
+	queue, _ := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now)))
+	claimed, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	// the worker never finished, so the task is handed out again once the claim expires
+	claimed, err = queue.ClaimDue(now.Add(2*time.Minute), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	assert.NoError(t, queue.Done(claimed[0]))
+	claimed, err = queue.ClaimDue(now.Add(10*time.Minute), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestRedisQueue_LoadSkipsKnownTasks(t *testing.T) {
+	queue, server := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+	task := testTask("user1", "blog1", now)
+
+	assert.NoError(t, queue.Push(task))
+	_, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+
+	// another replica booting up must not put the claimed task back
+	assert.NoError(t, queue.Load([]models.Job{task}))
+	assert.False(t, server.Exists(redisQueueKey))
+}
+
+func TestRedisQueue_LoadSkipsFinishedTasks(t *testing.T) {
+	queue, server := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+	task := testTask("user1", "blog1", now)
+
+	assert.NoError(t, queue.Push(task))
+	claimed, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.NoError(t, queue.Done(claimed[0]))
+
+	// a replica that read the task from the store before it finished loads it late
+	assert.NoError(t, queue.Load([]models.Job{task}))
+	assert.False(t, server.Exists(redisQueueKey))
+
+	// scheduling it again on purpose still works
+	assert.NoError(t, queue.Push(task))
+	claimed, err = queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
+
+func TestRedisQueue_RenewsClaims(t *testing.T) {
+	queue, _ := newTestRedisQueue(t, 200*time.Millisecond)
+	other := newRedisQueue(queue.client, 200*time.Millisecond)
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", time.Now())))
+	claimed, err := queue.ClaimDue(time.Now(), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	// the job runs past the visibility timeout, its claim is kept alive meanwhile
+	time.Sleep(600 * time.Millisecond)
+	stolen, err := other.ClaimDue(time.Now(), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Empty(t, stolen)
+	assert.NoError(t, queue.Done(claimed[0]))
+}
+
+func TestRedisQueue_DoneKeepsNewerClaim(t *testing.T) {
+	queue, server := newTestRedisQueue(t, time.Minute)
+	other := newRedisQueue(queue.client, time.Minute)
+	now := time.Now()
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now)))
+	claimed, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	// the claim expired without a renewal and another replica took the task over
+	reclaimed, err := other.ClaimDue(now.Add(2*time.Minute), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, reclaimed, 1)
+
+	assert.NoError(t, queue.Done(claimed[0]))
+	processing, err := server.ZMembers(redisProcessingKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blog1"}, processing)
+	assert.True(t, server.Exists(redisPayloadKey))
+
+	assert.NoError(t, other.Done(reclaimed[0]))
+	assert.False(t, server.Exists(redisProcessingKey))
+	assert.False(t, server.Exists(redisPayloadKey))
+}
+
+func TestRedisQueue_RemoveAndRequeue(t *testing.T) {
+	queue, _ := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now.Add(time.Hour))))
+	removed, err := queue.Remove("blog1")
+	assert.NoError(t, err)
+	assert.NotNil(t, removed)
+	assert.Equal(t, "user1", removed.Owner())
+
+	removed, err = queue.Remove("blog1")
+	assert.NoError(t, err)
+	assert.Nil(t, removed)
+
+	// a worker pushing a retry keeps the task alive after Done
+	task := testTask("user1", "blog2", now)
+	assert.NoError(t, queue.Push(task))
+	claimed, err := queue.ClaimDue(now, redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	retry := claimed[0].(models.ScheduledBlogData)
+	retry.NextAttemptAt = now.Add(time.Minute)
+	assert.NoError(t, queue.Push(retry))
+	assert.NoError(t, queue.Done(retry))
+
+	claimed, err = queue.ClaimDue(now.Add(2*time.Minute), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, "blog2", claimed[0].(models.ScheduledBlogData).ScheduledBlog.Id)
+}
+
+func TestRedisQueue_UpdateOnlyPending(t *testing.T) {
+	queue, _ := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now.Add(time.Hour))))
+	previous, err := queue.Update(testTask("user1", "blog1", now.Add(2*time.Hour)))
+	assert.NoError(t, err)
+	assert.NotNil(t, previous)
+	assert.WithinDuration(t, now.Add(time.Hour), previous.RunAt(), time.Millisecond)
+
+	next, ok, err := queue.NextRunAt()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.WithinDuration(t, now.Add(2*time.Hour), next, time.Millisecond)
+
+	// a claimed task isn't put back by an update
+	_, err = queue.ClaimDue(now.Add(3*time.Hour), redisClaimBatch)
+	assert.NoError(t, err)
+	previous, err = queue.Update(testTask("user1", "blog1", now.Add(4*time.Hour)))
+	assert.NoError(t, err)
+	assert.Nil(t, previous)
+	_, ok, err = queue.NextRunAt()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedisQueue_JobKinds(t *testing.T) {
+	queue, server := newTestRedisQueue(t, time.Minute)
+	now := time.Now()
+
+	email := models.NewEmailJob("user1", "someone@example.com", "hello", now)
+	assert.NoError(t, queue.Push(email))
+	assert.NoError(t, queue.Push(testTask("user1", "blog1", now.Add(-time.Second))))
+
+	// a payload pushed before jobs had a kind is still a blog task
+	legacy, err := json.Marshal(testTask("user1", "blog2", now.Add(-time.Second)))
+	assert.NoError(t, err)
+	server.HSet(redisPayloadKey, "blog2", string(legacy))
+	_, err = server.ZAdd(redisQueueKey, float64(now.Add(-time.Second).UnixMilli()), "blog2")
+	assert.NoError(t, err)
+
+	claimed, err := queue.ClaimDue(now.Add(time.Second), redisClaimBatch)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 3)
+	kinds := map[string]string{}
+	for _, job := range claimed {
+		kinds[job.Key()] = job.Kind()
+	}
+	assert.Equal(t, models.JobKindSendEmail, kinds[email.Key()])
+	assert.Equal(t, models.JobKindShareBlog, kinds["blog1"])
+	assert.Equal(t, models.JobKindShareBlog, kinds["blog2"])
+}
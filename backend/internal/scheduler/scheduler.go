@@ -3,76 +3,250 @@ package scheduler
 import (
 	"container/heap"
 	"context"
+	"errors"
 	"log"
+	"social-scribe/backend/internal/clock"
 	"social-scribe/backend/internal/models"
 	repo "social-scribe/backend/internal/repositories"
-	"social-scribe/backend/internal/services"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrTargetRunning is returned by RescheduleBlog when one of the targets is being
+// shared at that moment.
+var ErrTargetRunning = errors.New("a target of the blog is being shared right now")
+
+const (
+	defaultMaxAttempts = 5
+	baseRetryDelay     = 1 * time.Minute
+	maxRetryDelay      = 30 * time.Minute
+	// shares that came due longer than this before the scheduler started missed their
+	// time and go by the catch up policy
+	catchUpTolerance = 2 * time.Minute
 )
 
 type TaskHeap struct {
-	tasks    []models.ScheduledBlogData
+	tasks    []models.Job
 	indexMap map[string]int
 }
 
 func (h TaskHeap) Len() int { return len(h.tasks) }
 
 func (h TaskHeap) Less(i, j int) bool {
-	return h.tasks[i].ScheduledBlog.ScheduledTime.Before(h.tasks[j].ScheduledBlog.ScheduledTime)
+	return h.tasks[i].RunAt().Before(h.tasks[j].RunAt())
 }
 
 func (h TaskHeap) Swap(i, j int) {
 	h.tasks[i], h.tasks[j] = h.tasks[j], h.tasks[i]
-	h.indexMap[h.tasks[i].ScheduledBlog.Blog.Id] = i
-	h.indexMap[h.tasks[j].ScheduledBlog.Blog.Id] = j
+	h.indexMap[h.tasks[i].Key()] = i
+	h.indexMap[h.tasks[j].Key()] = j
 }
 
 func (h *TaskHeap) Push(x interface{}) {
-	task := x.(models.ScheduledBlogData)
-	h.tasks = append(h.tasks, task)
-	h.indexMap[task.ScheduledBlog.Blog.Id] = len(h.tasks) - 1
+	job := x.(models.Job)
+	h.tasks = append(h.tasks, job)
+	h.indexMap[job.Key()] = len(h.tasks) - 1
 }
 
 func (h *TaskHeap) Pop() interface{} {
 	n := len(h.tasks)
-	task := h.tasks[n-1]
+	job := h.tasks[n-1]
 	h.tasks = h.tasks[0 : n-1]
-	delete(h.indexMap, task.ScheduledBlog.Blog.Id)
-	return task
+	delete(h.indexMap, job.Key())
+	return job
 }
 
-func (h *TaskHeap) RemoveAt(index int) models.ScheduledBlogData {
+func (h *TaskHeap) RemoveAt(index int) models.Job {
 	n := len(h.tasks)
 	h.Swap(index, n-1)
 	removed := h.tasks[n-1]
 	h.tasks = h.tasks[:n-1]
-	delete(h.indexMap, removed.ScheduledBlog.Blog.Id)
+	delete(h.indexMap, removed.Key())
 	if index < len(h.tasks) {
 		heap.Fix(h, index)
 	}
 	return removed
 }
 
+// taskQueue is the storage the Scheduler agent pulls due jobs from. The in-process
+// heap is enough for a single backend, the Redis queue lets several replicas share
+// the same jobs without firing any of them twice.
+type taskQueue interface {
+	// Load adds the jobs stored in Mongo, jobs the queue already knows about are left alone.
+	Load(jobs []models.Job) error
+	// Push adds or replaces a job.
+	Push(job models.Job) error
+	// Remove drops a pending job and returns it, nil if no such job is pending.
+	Remove(key string) (models.Job, error)
+	// Update replaces a pending job in place and returns the job it replaced, nil if
+	// no such job is pending. Unlike Push it never brings back a claimed job.
+	Update(job models.Job) (models.Job, error)
+	// NextRunAt returns the run time of the earliest pending job.
+	NextRunAt() (time.Time, bool, error)
+	// ClaimDue takes up to limit jobs that are due at now off the queue.
+	ClaimDue(now time.Time, limit int) ([]models.Job, error)
+	// Done releases a claimed job once the worker is finished with it.
+	Done(job models.Job) error
+	// Pending lists the jobs waiting in the queue, claimed jobs aren't included.
+	Pending() ([]models.Job, error)
+}
+
+// memoryQueue keeps the tasks in a TaskHeap owned by this process.
+type memoryQueue struct {
+	heap *TaskHeap
+	mu   sync.Mutex
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{
+		heap: &TaskHeap{
+			tasks:    []models.Job{},
+			indexMap: make(map[string]int),
+		},
+	}
+}
+
+func (q *memoryQueue) Load(jobs []models.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, job := range jobs {
+		if _, ok := q.heap.indexMap[job.Key()]; ok {
+			continue
+		}
+		q.heap.tasks = append(q.heap.tasks, job)
+		q.heap.indexMap[job.Key()] = len(q.heap.tasks) - 1
+	}
+	heap.Init(q.heap)
+	return nil
+}
+
+func (q *memoryQueue) Push(job models.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if index, ok := q.heap.indexMap[job.Key()]; ok {
+		q.heap.tasks[index] = job
+		heap.Fix(q.heap, index)
+		return nil
+	}
+	heap.Push(q.heap, job)
+	return nil
+}
+
+func (q *memoryQueue) Remove(key string) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index, ok := q.heap.indexMap[key]
+	if !ok {
+		return nil, nil
+	}
+	return q.heap.RemoveAt(index), nil
+}
+
+func (q *memoryQueue) Update(job models.Job) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index, ok := q.heap.indexMap[job.Key()]
+	if !ok {
+		return nil, nil
+	}
+	previous := q.heap.tasks[index]
+	q.heap.tasks[index] = job
+	heap.Fix(q.heap, index)
+	return previous, nil
+}
+
+func (q *memoryQueue) NextRunAt() (time.Time, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.heap.Len() == 0 {
+		return time.Time{}, false, nil
+	}
+	return q.heap.tasks[0].RunAt(), true, nil
+}
+
+func (q *memoryQueue) ClaimDue(now time.Time, limit int) ([]models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var due []models.Job
+	for len(due) < limit && q.heap.Len() > 0 && !q.heap.tasks[0].RunAt().After(now) {
+		due = append(due, heap.Pop(q.heap).(models.Job))
+	}
+	return due, nil
+}
+
+func (q *memoryQueue) Done(job models.Job) error {
+	return nil
+}
+
+func (q *memoryQueue) Pending() ([]models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]models.Job{}, q.heap.tasks...), nil
+}
+
+// JobHandler runs a claimed job of one kind. Handlers own the job from then on, a
+// handler that wants the job to run again pushes it back itself.
+type JobHandler func(job models.Job) error
+
 type Scheduler struct {
-	heap      *TaskHeap
-	mu        sync.Mutex
-	ctx       context.Context
-	cancel    context.CancelFunc
-	newTaskCh chan struct{}
+	queue        taskQueue
+	pollInterval time.Duration
+	clock        clock.Clock
+	startedAt    time.Time
+	ctx          context.Context
+	cancel       context.CancelFunc
+	newTaskCh    chan struct{}
+	userLocks    sync.Map
+	copies       singleflight.Group
+	pool         *workerPool
+	agentAlive   atomic.Bool
+
+	handlersMu sync.RWMutex
+	handlers   map[string]JobHandler
 }
 
+// NewScheduler creates a scheduler that keeps its tasks in memory, only one backend
+// instance should run it.
 func NewScheduler() *Scheduler {
+	s := newScheduler(newMemoryQueue(), 0, poolConfigFromEnv(), clock.Real)
+	s.watchStoreFromEnv()
+	return s
+}
+
+// NewDistributedScheduler creates a scheduler backed by a Redis sorted set, any number
+// of backend replicas can run it against the same Redis and each task fires once.
+func NewDistributedScheduler(client *redis.Client) *Scheduler {
+	s := newScheduler(newRedisQueue(client, redisVisibilityTimeout()), redisPollInterval, poolConfigFromEnv(), clock.Real)
+	s.watchStoreFromEnv()
+	return s
+}
+
+func newScheduler(queue taskQueue, pollInterval time.Duration, config poolConfig, clk clock.Clock) *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 	s := &Scheduler{
-		ctx:       ctx,
-		cancel:    cancel,
-		newTaskCh: make(chan struct{}, 1),
-		heap: &TaskHeap{
-			tasks:    []models.ScheduledBlogData{},
-			indexMap: make(map[string]int),
-		},
+		queue:        queue,
+		pollInterval: pollInterval,
+		clock:        clk,
+		startedAt:    clk.Now(),
+		ctx:          ctx,
+		cancel:       cancel,
+		newTaskCh:    make(chan struct{}, 1),
+		handlers:     make(map[string]JobHandler),
 	}
+	s.pool = newWorkerPool(config, s.worker)
+	s.RegisterHandler(models.JobKindShareBlog, s.runShareJob)
+	s.RegisterHandler(models.JobKindSendEmail, runEmailJob)
 	if err := s.loadTasks(); err != nil {
 		log.Printf("[ERROR] Error loading tasks, Stopping the Scheduler: %v", err)
 		cancel()
@@ -82,6 +256,8 @@ func NewScheduler() *Scheduler {
 }
 
 func (s *Scheduler) runAgent() {
+	s.agentAlive.Store(true)
+	defer s.agentAlive.Store(false)
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("[ERROR] Agent panicked: %v", r)
@@ -91,13 +267,17 @@ func (s *Scheduler) runAgent() {
 
 	log.Println("[INFO] Scheduler agent started")
 
-	var timer *time.Timer
+	var timer clock.Timer
 
 	for {
-		s.mu.Lock()
-		if s.heap.Len() == 0 {
-			s.mu.Unlock()
+		nextRunAt, ok, err := s.queue.NextRunAt()
+		if err != nil {
+			log.Printf("[ERROR] Error reading the next task: %v", err)
+		}
 
+		// with a shared queue other replicas add tasks too, so we poll instead of
+		// only waiting for our own AddJob calls
+		if !ok && s.pollInterval == 0 {
 			select {
 			case <-s.newTaskCh:
 				log.Println("[INFO] New task added, rechecking heap")
@@ -108,56 +288,53 @@ func (s *Scheduler) runAgent() {
 			}
 		}
 
-		nextTask := s.heap.tasks[0]
-		scheduledTimeUTC := nextTask.ScheduledBlog.ScheduledTime.UTC()
-		timeUntil := time.Until(scheduledTimeUTC)
-		if timeUntil <= 0 {
-			timeUntil = 1 * time.Millisecond
+		timeUntil := s.pollInterval
+		if ok {
+			timeUntil = nextRunAt.UTC().Sub(s.clock.Now())
+			if s.pollInterval > 0 && timeUntil > s.pollInterval {
+				timeUntil = s.pollInterval
+			}
 		}
-		s.mu.Unlock()
-
-		if timeUntil == 1*time.Millisecond {
-			s.mu.Lock()
-			if s.heap.Len() > 0 {
-				task := heap.Pop(s.heap).(models.ScheduledBlogData)
-				s.mu.Unlock()
-				go s.worker(task)
-			} else {
-				s.mu.Unlock()
+
+		if ok && timeUntil <= 0 {
+			if s.pool.Free() == 0 {
+				// every worker is busy or the line of capped jobs is full, due jobs stay in the
+				// queue until a worker is free
+				select {
+				case <-s.pool.Freed():
+					continue
+				case <-s.ctx.Done():
+					return
+				}
 			}
-			continue
+			if s.dispatchDue() {
+				continue
+			}
+			timeUntil = time.Second
 		}
 
 		if timer == nil {
-			timer = time.NewTimer(timeUntil)
+			timer = s.clock.NewTimer(timeUntil)
 		} else {
 			if !timer.Stop() {
 				select {
-				case <-timer.C:
+				case <-timer.C():
 				default:
 				}
 			}
-			log.Printf("[INFO] Resetting timer to %v", timeUntil)
 			timer.Reset(timeUntil)
 		}
 
 		select {
-		case <-timer.C:
-			s.mu.Lock()
-			if s.heap.Len() > 0 {
-				task := heap.Pop(s.heap).(models.ScheduledBlogData)
-				s.mu.Unlock()
-				go s.worker(task)
-			} else {
-				s.mu.Unlock()
-			}
+		case <-timer.C():
+			s.dispatchDue()
 		case <-s.newTaskCh:
 			continue
 		case <-s.ctx.Done():
 			if timer != nil {
 				if !timer.Stop() {
 					select {
-					case <-timer.C:
+					case <-timer.C():
 					default:
 					}
 				}
@@ -167,126 +344,342 @@ func (s *Scheduler) runAgent() {
 	}
 }
 
-func (s *Scheduler) worker(task models.ScheduledBlogData) {
+// dispatchDue claims as many due jobs as the worker pool has room for and hands them
+// to it, it returns false when the queue couldn't be read.
+func (s *Scheduler) dispatchDue() bool {
+	free := s.pool.Free()
+	if free == 0 {
+		return true
+	}
+	jobs, err := s.queue.ClaimDue(s.clock.Now(), free)
+	if err != nil {
+		log.Printf("[ERROR] Error claiming due tasks: %v", err)
+		return false
+	}
+	for _, job := range jobs {
+		// Stop may have closed the pool while the jobs were claimed
+		if !s.pool.Submit(job) {
+			if err := s.queue.Push(job); err != nil {
+				log.Printf("[ERROR] Error putting back job %s: %v", job.Key(), err)
+			}
+		}
+	}
+	return true
+}
 
-	if task.EmailId != "" {
-		if err := services.SendEmail(task.EmailId, task.Message); err != nil {
-			log.Printf("[ERROR] Sending OTP email to %s: %v", task.EmailId, err)
+// Clock returns the clock the scheduler runs on, validation should read the time from
+// it too so both agree on what is in the past.
+func (s *Scheduler) Clock() clock.Clock {
+	return s.clock
+}
+
+// RegisterHandler sets the handler of a job kind, replacing the one registered before.
+func (s *Scheduler) RegisterHandler(kind string, handler JobHandler) {
+	s.handlersMu.Lock()
+	defer s.handlersMu.Unlock()
+	s.handlers[kind] = handler
+}
+
+func (s *Scheduler) worker(job models.Job) {
+	defer func() {
+		if err := s.queue.Done(job); err != nil {
+			log.Printf("[ERROR] Error releasing job %s: %v", job.Key(), err)
 		}
-		delErr := repo.DeleteScheduledTask(task)
-		if delErr != nil {
-			log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+	}()
+
+	s.handlersMu.RLock()
+	handler, ok := s.handlers[job.Kind()]
+	s.handlersMu.RUnlock()
+	if !ok {
+		log.Printf("[ERROR] No handler for %s job %s, dropping it", job.Kind(), job.Key())
+		if err := repo.DeleteScheduledJob(job); err != nil {
+			log.Printf("[ERROR] Error deleting scheduled job: %v", err)
 		}
+		return
+	}
+	if err := handler(job); err != nil {
+		log.Printf("[ERROR] Error running %s job %s: %v", job.Kind(), job.Key(), err)
+	}
+}
+
+// requeue pushes a job back onto the queue without storing it again.
+func (s *Scheduler) requeue(job models.Job) {
+	if err := s.queue.Push(job); err != nil {
+		log.Printf("[ERROR] Error requeueing job %s: %v", job.Key(), err)
+		return
+	}
+	s.notify()
+}
+
+// notify wakes the agent up so it rechecks the queue.
+func (s *Scheduler) notify() {
+	select {
+	case s.newTaskCh <- struct{}{}:
+	default:
+	}
+}
+
+// lockUser serializes the read-modify-write of a user document between the workers
+// of this process, targets of the same blog often fire together.
+func (s *Scheduler) lockUser(userId string) func() {
+	value, _ := s.userLocks.LoadOrStore(userId, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *Scheduler) loadTasks() error {
+	jobs, err := repo.GetScheduledJobs()
+	if err != nil {
+		log.Printf("[ERROR] Error loading jobs: %v", err)
+		return err
+	}
+
+	if err := s.queue.Load(jobs); err != nil {
+		log.Printf("[ERROR] Error loading jobs into the queue: %v", err)
+		return err
+	}
+	log.Printf("[INFO] Loaded %d jobs successfully into the queue", len(jobs))
+	return nil
+}
 
-	} else {
-		log.Printf("[INFO] Worker executing task for user %v with blog %v, for platforms %v", task.UserID, task.ScheduledBlog.Blog.Id, task.ScheduledBlog.Platforms)
+// AddJob stores a job and puts it on the queue.
+func (s *Scheduler) AddJob(job models.Job) error {
+	err := repo.StoreScheduledJob(job)
+	if err != nil {
+		return err
+	}
+	if err := s.queue.Push(job); err != nil {
+		log.Printf("[ERROR] Error adding job to the queue: %v", err)
+		return err
+	}
 
-		user, err := repo.GetUserById(task.UserID)
-		if err != nil || user == nil {
-			log.Printf("[ERROR] Error getting user or user not found: %v", task.UserID)
-			if delErr := repo.DeleteScheduledTask(task); delErr != nil {
-				log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+	s.notify()
+	return nil
+}
+
+// AddScheduledBlog adds a task for every platform target of the blog, each target
+// fires on its own. When one of them can't be added the others are removed again.
+func (s *Scheduler) AddScheduledBlog(userId string, blog models.ScheduledBlog) error {
+	for _, target := range blog.Targets {
+		if err := s.AddJob(targetTask(userId, blog, target)); err != nil {
+			if rmErr := s.RemoveTask(blog); rmErr != nil {
+				log.Printf("[ERROR] Error rolling back targets of blog %s: %v", blog.Id, rmErr)
 			}
-			return
+			return err
 		}
+	}
+	return nil
+}
 
-		blogId := task.ScheduledBlog.Blog.Id
-		platforms := task.ScheduledBlog.Platforms
+// targetTask builds the task of a single platform target of a scheduled blog.
+func targetTask(userId string, blog models.ScheduledBlog, target models.PlatformTarget) models.ScheduledBlogData {
+	task := models.ScheduledBlogData{
+		UserID:      userId,
+		Platform:    target.Platform,
+		Status:      models.TaskStatusPending,
+		MaxAttempts: defaultMaxAttempts,
+	}
+	task.ScheduledBlog = blog
+	task.ScheduledBlog.Platforms = []string{target.Platform}
+	task.ScheduledBlog.ScheduledTime = target.ScheduledTime
+	task.ScheduledBlog.Targets = nil
+	task.ScheduledBlog.Copy = nil
+	if blog.Recurrence != nil {
+		recurrence := *blog.Recurrence
+		task.ScheduledBlog.Recurrence = &recurrence
+	}
+	return task
+}
 
-		processErr := services.ProcessSharedBlog(user, blogId, platforms)
-		if processErr != nil {
-			log.Printf("[ERROR] Error processing shared blog for blog id %s and user id %s: %v", blogId, task.UserID, processErr)
+// RescheduleBlog moves the pending targets of a scheduled blog from old to updated.
+// Targets pending on both sides are updated in place in the queue, the others are
+// removed or added, and the user's scheduled blog is saved together with its stored
+// tasks. When a target is being shared right now nothing changes and ErrTargetRunning
+// is returned.
+func (s *Scheduler) RescheduleBlog(userId string, old, updated models.ScheduledBlog) error {
+	unlock := s.lockUser(userId)
+	defer unlock()
+
+	pendingOld := map[string]bool{}
+	for _, target := range old.Targets {
+		if !target.Settled() {
+			pendingOld[target.Platform] = true
 		}
+	}
 
-		delErr := repo.DeleteScheduledTask(task)
-		if delErr != nil {
-			log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+	var kept, added, stored []models.ScheduledBlogData
+	pendingNew := map[string]bool{}
+	for _, target := range updated.Targets {
+		if target.Settled() {
+			continue
+		}
+		pendingNew[target.Platform] = true
+		task := targetTask(userId, updated, target)
+		stored = append(stored, task)
+		if pendingOld[target.Platform] {
+			kept = append(kept, task)
+		} else {
+			added = append(added, task)
 		}
+	}
 
-		removed := false
-		for i, blog := range user.ScheduledBlogs {
-			if blog.Id == blogId {
-				user.ScheduledBlogs = append(user.ScheduledBlogs[:i], user.ScheduledBlogs[i+1:]...)
-				removed = true
-				break
+	// a blog scheduled before targets existed sits in the queue under its task key alone
+	var removedKeys []string
+	if len(old.Targets) == 0 {
+		removedKeys = append(removedKeys, models.ShareJobKey(old.TaskKey(), ""))
+	}
+	for platform := range pendingOld {
+		if !pendingNew[platform] {
+			removedKeys = append(removedKeys, models.ShareJobKey(old.TaskKey(), platform))
+		}
+	}
+
+	var replaced, removed []models.Job
+	rollback := func() {
+		for _, job := range replaced {
+			if _, err := s.queue.Update(job); err != nil {
+				log.Printf("[ERROR] Error restoring job %s: %v", job.Key(), err)
 			}
 		}
-		if !removed {
-			log.Printf("[WARN] Blog with id %s not found in user's scheduled blogs", blogId)
+		for _, job := range removed {
+			if err := s.queue.Push(job); err != nil {
+				log.Printf("[ERROR] Error restoring job %s: %v", job.Key(), err)
+			}
 		}
+	}
 
-		updErr := repo.UpdateUser(task.UserID, user)
-		if updErr != nil {
-			log.Printf("[ERROR] Error updating user: %v", updErr)
+	for _, task := range kept {
+		previous, err := s.queue.Update(task)
+		if err != nil {
+			rollback()
+			return err
 		}
-
-		if processErr != nil {
-			log.Printf("[INFO] Task executed with errors for blog with ID %s and user ID %s, error: %v", blogId, task.UserID, processErr)
-		} else {
-			log.Printf("[INFO] Task executed successfully for blog with ID %s and user ID %s at %v", blogId, task.UserID, task.ScheduledBlog.ScheduledTime)
+		if previous == nil {
+			rollback()
+			return ErrTargetRunning
 		}
+		replaced = append(replaced, previous)
+	}
+	for _, key := range removedKeys {
+		job, err := s.queue.Remove(key)
+		if err != nil {
+			rollback()
+			return err
+		}
+		if job == nil {
+			rollback()
+			return ErrTargetRunning
+		}
+		removed = append(removed, job)
 	}
-}
 
-func (s *Scheduler) loadTasks() error {
-	tasks, err := repo.GetScheduledTasks()
-	if err != nil {
-		log.Printf("[ERROR] Error loading tasks: %v", err)
+	if err := repo.SaveRescheduledBlog(userId, updated, stored, removed); err != nil {
+		rollback()
 		return err
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.heap = &TaskHeap{
-		tasks:    tasks,
-		indexMap: make(map[string]int),
-	}
-	for i, task := range s.heap.tasks {
-		s.heap.indexMap[task.ScheduledBlog.Blog.Id] = i
+	// the new targets are stored already, a failed push is picked up again on restart
+	for _, task := range added {
+		if err := s.queue.Push(task); err != nil {
+			log.Printf("[ERROR] Error adding task to the queue: %v", err)
+			return err
+		}
 	}
-	heap.Init(s.heap)
-	log.Printf("[INFO] Loaded %d tasks successfully into heap", len(tasks))
+	s.notify()
 	return nil
 }
 
-func (s *Scheduler) AddTask(task models.ScheduledBlogData) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	err := repo.StoreScheduledTask(task)
-	if err != nil {
-		return err
+// RemoveTask removes the pending tasks of a scheduled blog, both the per platform
+// targets and a task from before targets existed.
+func (s *Scheduler) RemoveTask(blog models.ScheduledBlog) error {
+	keys := []string{models.ShareJobKey(blog.TaskKey(), "")}
+	for _, platform := range blog.Platforms {
+		keys = append(keys, models.ShareJobKey(blog.TaskKey(), platform))
 	}
-	heap.Push(s.heap, task)
 
-	select {
-	case s.newTaskCh <- struct{}{}:
-	default:
+	for _, key := range keys {
+		job, err := s.queue.Remove(key)
+		if err != nil {
+			log.Printf("[ERROR] Error removing task from the queue: %v", err)
+			return err
+		}
+		if job == nil {
+			continue
+		}
+		err = repo.DeleteScheduledJob(job)
+		if err != nil {
+			log.Printf("[ERROR] Error deleting task: %v", err)
+			return err
+		}
 	}
+	s.notify() // so we have to notify the agent to recheck the queue
 	return nil
 }
 
-func (s *Scheduler) RemoveTask(blogId string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Stats is a snapshot of what the scheduler is doing, for operators.
+type Stats struct {
+	QueueDepth   int            `json:"queue_depth"`
+	NextRunAt    *time.Time     `json:"next_run_at,omitempty"`
+	TasksPerUser map[string]int `json:"tasks_per_user"`
+	InFlight     int            `json:"in_flight"`
+	Waiting      int            `json:"waiting"`
+	Workers      int            `json:"workers"`
+	AgentAlive   bool           `json:"agent_alive"`
+}
 
-	index, ok := s.heap.indexMap[blogId]
-	if !ok {
-		return nil
+// Stats reports the queued jobs, the jobs on the worker pool and whether the agent is
+// still running. With the Redis queue the queued jobs are those of every replica, the
+// workers are only the ones of this process.
+func (s *Scheduler) Stats() (Stats, error) {
+	stats := Stats{
+		TasksPerUser: map[string]int{},
+		Workers:      s.pool.config.Workers,
+		AgentAlive:   s.agentAlive.Load(),
 	}
-	task := s.heap.RemoveAt(index)
-	err := repo.DeleteScheduledTask(task)
+	stats.InFlight, stats.Waiting = s.pool.Stats()
+
+	pending, err := s.queue.Pending()
 	if err != nil {
-		log.Printf("[ERROR] Error deleting task: %v", err)
-		return err
+		return stats, err
 	}
-	select {
-	case s.newTaskCh <- struct{}{}: // so we have to notify the agent to recheck the heap
-	default:
+	stats.QueueDepth = len(pending)
+	for _, job := range pending {
+		stats.TasksPerUser[job.Owner()]++
+		if runAt := job.RunAt(); stats.NextRunAt == nil || runAt.Before(*stats.NextRunAt) {
+			stats.NextRunAt = &runAt
+		}
 	}
-	return nil
+	return stats, nil
+}
+
+// Shutdown stops the scheduler and waits for the running jobs to finish, up to the
+// deadline of ctx. Jobs still running at the deadline go back on the queue, so they
+// run again after the restart or on another replica.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+
+	running := s.pool.Wait(ctx)
+	if len(running) == 0 {
+		log.Println("[INFO] Scheduler drained")
+		return nil
+	}
+	for _, job := range running {
+		log.Printf("[WARN] Job %s is still running at shutdown, putting it back on the queue", job.Key())
+		if err := s.queue.Push(job); err != nil {
+			log.Printf("[ERROR] Error putting back job %s: %v", job.Key(), err)
+		}
+	}
+	return ctx.Err()
 }
 
+// Stop stops the agent and the workers, jobs that were claimed but not started yet go
+// back on the queue. Running jobs are left to finish, see Shutdown to wait for them.
 func (s *Scheduler) Stop() {
 	s.cancel()
+	for _, job := range s.pool.Close() {
+		if err := s.queue.Push(job); err != nil {
+			log.Printf("[ERROR] Error putting back job %s: %v", job.Key(), err)
+		}
+	}
 }
@@ -0,0 +1,369 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/clock"
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	repo.GetScheduledJobs = func() ([]models.Job, error) {
+		return []models.Job{}, nil
+	}
+	repo.StoreScheduledJob = func(job models.Job) error {
+		return nil
+	}
+	repo.InsertShareRun = func(run *models.ShareRun) error {
+		return nil
+	}
+	s := newScheduler(newMemoryQueue(), 0, poolConfig{Workers: 2}, clock.Real)
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func scheduledBlog(blogId string, targets ...models.PlatformTarget) models.ScheduledBlog {
+	blog := models.ScheduledBlog{Targets: targets}
+	blog.Id = blogId
+	blog.NormalizeTargets()
+	return blog
+}
+
+func TestAddScheduledBlog_SameBlogManyTimes(t *testing.T) {
+	s := newTestScheduler(t)
+	at := time.Now().Add(time.Hour)
+	launch := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at})
+	launch.TaskId = models.NewTaskId()
+	followUp := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at.Add(24 * time.Hour)})
+	followUp.TaskId = models.NewTaskId()
+	otherUser := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at})
+	otherUser.TaskId = models.NewTaskId()
+
+	assert.NoError(t, s.AddScheduledBlog("user1", launch))
+	assert.NoError(t, s.AddScheduledBlog("user1", followUp))
+	assert.NoError(t, s.AddScheduledBlog("user2", otherUser))
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 3)
+
+	var deleted []models.Job
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		deleted = append(deleted, job)
+		return nil
+	}
+	assert.NoError(t, s.RemoveTask(launch))
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, launch.TaskId, deleted[0].(models.ScheduledBlogData).ScheduledBlog.TaskId)
+
+	pending, err = s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+}
+
+func TestRescheduleBlog_UpdatesInPlace(t *testing.T) {
+	s := newTestScheduler(t)
+	at := time.Now().Add(time.Hour)
+	old := scheduledBlog("blog1",
+		models.PlatformTarget{Platform: "twitter", ScheduledTime: at},
+		models.PlatformTarget{Platform: "linkedin", ScheduledTime: at},
+	)
+	assert.NoError(t, s.AddScheduledBlog("user1", old))
+
+	var saved []models.ScheduledBlogData
+	var deleted []models.Job
+	repo.SaveRescheduledBlog = func(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, removed []models.Job) error {
+		saved, deleted = tasks, removed
+		return nil
+	}
+
+	later := at.Add(2 * time.Hour)
+	updated := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: later})
+	assert.NoError(t, s.RescheduleBlog("user1", old, updated))
+
+	queue := s.queue.(*memoryQueue)
+	assert.Equal(t, 1, queue.heap.Len())
+	index, ok := queue.heap.indexMap[models.ShareJobKey("blog1", "twitter")]
+	assert.True(t, ok)
+	assert.Equal(t, later, queue.heap.tasks[index].RunAt())
+
+	assert.Len(t, saved, 1)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, models.ShareJobKey("blog1", "linkedin"), deleted[0].Key())
+}
+
+func TestRescheduleBlog_TargetRunning(t *testing.T) {
+	s := newTestScheduler(t)
+	at := time.Now().Add(time.Hour)
+	old := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at})
+	assert.NoError(t, s.AddScheduledBlog("user1", old))
+
+	// the agent claimed the task, so it is running and can't be moved anymore
+	_, err := s.queue.ClaimDue(at, 10)
+	assert.NoError(t, err)
+
+	saveCalled := false
+	repo.SaveRescheduledBlog = func(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, removed []models.Job) error {
+		saveCalled = true
+		return nil
+	}
+	updated := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at.Add(time.Hour)})
+	assert.ErrorIs(t, s.RescheduleBlog("user1", old, updated), ErrTargetRunning)
+	assert.False(t, saveCalled)
+}
+
+func TestRescheduleBlog_RollsBackQueue(t *testing.T) {
+	s := newTestScheduler(t)
+	at := time.Now().Add(time.Hour)
+	old := scheduledBlog("blog1",
+		models.PlatformTarget{Platform: "twitter", ScheduledTime: at},
+		models.PlatformTarget{Platform: "linkedin", ScheduledTime: at},
+	)
+	assert.NoError(t, s.AddScheduledBlog("user1", old))
+
+	repo.SaveRescheduledBlog = func(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, removed []models.Job) error {
+		return assert.AnError
+	}
+	updated := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: at.Add(time.Hour)})
+	assert.Error(t, s.RescheduleBlog("user1", old, updated))
+
+	queue := s.queue.(*memoryQueue)
+	assert.Equal(t, 2, queue.heap.Len())
+	next, ok, err := queue.NextRunAt()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, at, next)
+}
+
+func TestStats(t *testing.T) {
+	s := newTestScheduler(t)
+	at := time.Now().Add(time.Hour)
+	assert.NoError(t, s.AddScheduledBlog("user1", scheduledBlog("blog1",
+		models.PlatformTarget{Platform: "twitter", ScheduledTime: at.Add(time.Hour)},
+		models.PlatformTarget{Platform: "linkedin", ScheduledTime: at},
+	)))
+	assert.NoError(t, s.AddScheduledBlog("user2", scheduledBlog("blog2",
+		models.PlatformTarget{Platform: "twitter", ScheduledTime: at.Add(2 * time.Hour)},
+	)))
+
+	assert.Eventually(t, func() bool { return s.agentAlive.Load() }, time.Second, 10*time.Millisecond)
+	stats, err := s.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.QueueDepth)
+	assert.Equal(t, map[string]int{"user1": 2, "user2": 1}, stats.TasksPerUser)
+	assert.Equal(t, at, *stats.NextRunAt)
+	assert.Equal(t, 0, stats.InFlight)
+	assert.True(t, stats.AgentAlive)
+
+	s.Stop()
+	assert.Eventually(t, func() bool { return !s.agentAlive.Load() }, time.Second, 10*time.Millisecond)
+}
+
+func TestShutdown_DrainsRunningJobs(t *testing.T) {
+	s := newTestScheduler(t)
+	finished := make(chan struct{})
+	s.RegisterHandler(models.JobKindShareBlog, func(job models.Job) error {
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", time.Now())))
+	assert.Eventually(t, func() bool { running, _ := s.pool.Stats(); return running == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+	select {
+	case <-finished:
+	default:
+		t.Fatal("shutdown returned before the running job finished")
+	}
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestShutdown_RequeuesJobsPastDeadline(t *testing.T) {
+	s := newTestScheduler(t)
+	release := make(chan struct{})
+	defer close(release)
+	s.RegisterHandler(models.JobKindShareBlog, func(job models.Job) error {
+		<-release
+		return nil
+	})
+
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", time.Now())))
+	assert.Eventually(t, func() bool { running, _ := s.pool.Stats(); return running == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "blog1", pending[0].Key())
+}
+
+func TestStop_PutsBackJobsClaimedWhileStopping(t *testing.T) {
+	s := newTestScheduler(t)
+	s.Stop()
+
+	// the agent claimed the job right before the pool closed
+	assert.NoError(t, s.queue.Push(testTask("user1", "blog1", time.Now())))
+	assert.True(t, s.dispatchDue())
+
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "blog1", pending[0].Key())
+}
+
+func TestRunShareTask_SkipsMissedShare(t *testing.T) {
+	s := newTestScheduler(t)
+	due := time.Now().Add(-3 * time.Hour)
+	blog := scheduledBlog("blog1",
+		models.PlatformTarget{Platform: "twitter", ScheduledTime: due},
+		models.PlatformTarget{Platform: "linkedin", ScheduledTime: time.Now().Add(time.Hour)},
+	)
+	user := &models.User{
+		ScheduledBlogs: []models.ScheduledBlog{blog},
+		CatchUpPolicy:  &models.CatchUpPolicy{Mode: models.CatchUpSkipAfterGrace, GraceMinutes: 60},
+	}
+	repo.GetUserById = func(userId string) (*models.User, error) {
+		return user, nil
+	}
+	repo.UpdateUser = func(userId string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	var deleted []models.Job
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		deleted = append(deleted, job)
+		return nil
+	}
+
+	var runs []*models.ShareRun
+	repo.InsertShareRun = func(run *models.ShareRun) error {
+		runs = append(runs, run)
+		return nil
+	}
+
+	s.runShareTask(targetTask("user1", blog, blog.Targets[0]))
+
+	assert.Len(t, runs, 1)
+	assert.Equal(t, models.ShareTriggerScheduled, runs[0].Trigger)
+	assert.Equal(t, []models.PlatformOutcome{{Platform: "twitter", Status: models.TaskStatusSkipped}}, runs[0].Platforms)
+	assert.Len(t, deleted, 1)
+	assert.Len(t, user.ScheduledBlogs, 1)
+	assert.Equal(t, models.TaskStatusSkipped, user.ScheduledBlogs[0].Targets[0].Status)
+	assert.Equal(t, "", user.ScheduledBlogs[0].Targets[1].Status)
+	assert.Len(t, user.Notifications, 1)
+	assert.Contains(t, user.Notifications[0], "Skipped sharing")
+}
+
+func TestRunShareTask_QueuedShareIsNotMissed(t *testing.T) {
+	s := newTestScheduler(t)
+	// the share came due while the scheduler was running and waited in line since
+	s.startedAt = time.Now().Add(-4 * time.Hour)
+	blog := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: time.Now().Add(-3 * time.Hour)})
+	user := &models.User{
+		ScheduledBlogs:  []models.ScheduledBlog{blog},
+		CatchUpPolicy:   &models.CatchUpPolicy{Mode: models.CatchUpSkip},
+		PublishingRules: blackoutToday(models.BlackoutDefer),
+	}
+	repo.GetUserById = func(userId string) (*models.User, error) {
+		return user, nil
+	}
+	repo.UpdateUser = func(userId string, updated *models.User) error {
+		user = updated
+		return nil
+	}
+	var updated []models.ScheduledBlogData
+	repo.UpdateScheduledTask = func(task models.ScheduledBlogData) error {
+		updated = append(updated, task)
+		return nil
+	}
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		return nil
+	}
+
+	s.runShareTask(targetTask("user1", blog, blog.Targets[0]))
+
+	// it goes on to the publishing rules instead of being skipped
+	assert.Len(t, updated, 1)
+	assert.NotEqual(t, models.TaskStatusSkipped, user.ScheduledBlogs[0].Targets[0].Status)
+	assert.Contains(t, user.ScheduledBlogs[0].Targets[0].Result, "blackout: freeze")
+	for _, notification := range user.Notifications {
+		assert.NotContains(t, notification, "Skipped sharing")
+	}
+}
+
+func TestRunShareTask_GeneratesCopyWithoutUserLock(t *testing.T) {
+	s := newTestScheduler(t)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	httpmock.RegisterResponder("POST", "https://gql.hashnode.com", func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"data": map[string]interface{}{"post": map[string]interface{}{
+			"id": "blog1", "title": "Go tips", "url": "https://me.hashnode.dev/go-tips",
+		}}})
+	})
+	httpmock.RegisterResponder("POST", `=~^https://generativelanguage\.googleapis\.com/`,
+		httpmock.NewStringResponder(200, `{"candidates": [{"content": {"parts": [{"text": "[TWITTER]\nGo tips https://me.hashnode.dev/go-tips"}]}}]}`))
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets",
+		httpmock.NewStringResponder(201, `{"data": {"id": "42"}}`))
+
+	blog := scheduledBlog("blog1", models.PlatformTarget{Platform: "twitter", ScheduledTime: time.Now()})
+	user := &models.User{Verified: true, XVerified: true, ScheduledBlogs: []models.ScheduledBlog{blog}}
+	var mu sync.Mutex
+	repo.GetUserById = func(userId string) (*models.User, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return user, nil
+	}
+	repo.UpdateUser = func(userId string, updated *models.User) error {
+		mu.Lock()
+		defer mu.Unlock()
+		user = updated
+		return nil
+	}
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.runShareTask(targetTask("user1", blog, blog.Targets[0]))
+		close(done)
+	}()
+	<-started
+
+	// another job of the user gets the lock while the copy is being generated
+	locked := make(chan struct{})
+	go func() {
+		s.lockUser("user1")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("the user's lock is held while the copy is generated")
+	}
+
+	close(release)
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, user.SharedBlogs, 1)
+}
@@ -0,0 +1,509 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+	"social-scribe/backend/internal/services"
+)
+
+// runShareJob is the handler of share_blog jobs.
+func (s *Scheduler) runShareJob(job models.Job) error {
+	task, ok := job.(models.ScheduledBlogData)
+	if !ok {
+		return fmt.Errorf("unexpected %T payload for a %s job", job, models.JobKindShareBlog)
+	}
+	s.runShareTask(task)
+	return nil
+}
+
+// runShareTask shares a scheduled blog to the platforms of the task. Every target of
+// a schedule uses the same AI copy, the first target that fires generates it.
+func (s *Scheduler) runShareTask(task models.ScheduledBlogData) {
+	blogId := task.ScheduledBlog.Blog.Id
+	platforms := task.ScheduledBlog.Platforms
+	log.Printf("[INFO] Worker executing task for user %v with blog %v, for platforms %v", task.UserID, blogId, platforms)
+
+	unlock := s.lockUser(task.UserID)
+	user, err := repo.GetUserById(task.UserID)
+	if err != nil || user == nil {
+		unlock()
+		log.Printf("[ERROR] Error getting user or user not found: %v", task.UserID)
+		if delErr := repo.DeleteScheduledJob(task); delErr != nil {
+			log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+		}
+		return
+	}
+	run := services.StartShareRun(task.UserID, blogId, models.ShareTriggerScheduled)
+	run.TaskId = task.ScheduledBlog.TaskKey()
+	run.Attempt = task.Attempts + 1
+	if task.ScheduledBlog.Recurrence != nil {
+		run.Occurrence = task.ScheduledBlog.Recurrence.Occurrences
+	}
+	// a share that missed its time, because the backend was down, goes by the catch up
+	// policy. Shares that came due while it was up are only late from waiting in line.
+	if missed := s.startedAt.Sub(task.RunAt()); missed > catchUpTolerance {
+		late := s.clock.Now().Sub(task.RunAt())
+		if catchUpPolicy(user, task).ShouldSkip(late) {
+			s.skipMissedShare(task, user, late)
+			unlock()
+			recordSkippedRun(run, task, late)
+			return
+		}
+		notifyLateShare(task, user, late)
+	}
+	// quiet hours and blackout dates hold the share back, unless the user released it
+	if rules := publishingRules(user); rules != nil && !task.Approved {
+		if allowedAt, reason := rules.NextAllowed(s.clock.Now()); reason != "" {
+			s.holdBackShare(task, user, rules.Action, allowedAt, reason)
+			unlock()
+			return
+		}
+	}
+	shareCopy := storedCopy(task, user)
+	unlock()
+	var processErr error
+	if shareCopy == nil {
+		shareCopy, processErr = s.generateCopy(task, user)
+	}
+
+	var outcomes []models.PlatformOutcome
+	if processErr == nil {
+		outcomes, processErr = services.PublishShareCopy(user, shareCopy, platforms)
+	}
+	services.FinishShareRun(run, shareCopy, platforms, outcomes, processErr)
+
+	// the user may have changed while we were posting, so the results go on a fresh copy
+	unlock = s.lockUser(task.UserID)
+	defer unlock()
+	user, err = repo.GetUserById(task.UserID)
+	if err != nil || user == nil {
+		log.Printf("[ERROR] Error getting user or user not found: %v", task.UserID)
+		if delErr := repo.DeleteScheduledJob(task); delErr != nil {
+			log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+		}
+		return
+	}
+
+	if processErr != nil {
+		log.Printf("[ERROR] Error processing shared blog for blog id %s and user id %s (attempt %d/%d): %v", blogId, task.UserID, task.Attempts+1, task.MaxAttempts, processErr)
+		s.handleFailure(task, user, shareCopy, processErr)
+		return
+	}
+	s.handleSuccess(task, user, shareCopy)
+}
+
+// copyOccurrence is the occurrence of a recurring task the copy is generated for.
+func copyOccurrence(task models.ScheduledBlogData) int {
+	if task.ScheduledBlog.Recurrence != nil {
+		return task.ScheduledBlog.Recurrence.Occurrences
+	}
+	return 0
+}
+
+// storedCopy returns the copy another target of the same occurrence already generated
+// and kept on the user's scheduled blog, nil when there is none yet.
+func storedCopy(task models.ScheduledBlogData, user *models.User) *models.ShareCopy {
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+	if index < 0 {
+		return nil
+	}
+	if existing := user.ScheduledBlogs[index].Copy; existing != nil && existing.Occurrence == copyOccurrence(task) && existing.HasPosts(task.ScheduledBlog.Platforms) {
+		return existing
+	}
+	return nil
+}
+
+// generateCopy asks the AI for the copy of the task's occurrence and keeps it on the
+// user's scheduled blog. The call runs without the user's lock so a slow model
+// doesn't hold up the user's other jobs, targets of the same occurrence that fire
+// together wait for a single call.
+func (s *Scheduler) generateCopy(task models.ScheduledBlogData, user *models.User) (*models.ShareCopy, error) {
+	if !user.Verified {
+		return nil, fmt.Errorf("user is not verified")
+	}
+	occurrence := copyOccurrence(task)
+	key := fmt.Sprintf("%s:%s#%d", task.UserID, task.ScheduledBlog.TaskKey(), occurrence)
+	generated, err, _ := s.copies.Do(key, func() (interface{}, error) {
+		shareCopy, err := services.GenerateShareCopy(user, task.ScheduledBlog.Blog.Id)
+		if err != nil {
+			return nil, err
+		}
+		shareCopy.Occurrence = occurrence
+		return s.saveCopy(task, shareCopy), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return generated.(*models.ShareCopy), nil
+}
+
+// saveCopy keeps the copy on a fresh read of the user. A copy another target stored
+// meanwhile wins, so every target of the occurrence posts the same copy.
+func (s *Scheduler) saveCopy(task models.ScheduledBlogData, shareCopy *models.ShareCopy) *models.ShareCopy {
+	unlock := s.lockUser(task.UserID)
+	defer unlock()
+	user, err := repo.GetUserById(task.UserID)
+	if err != nil || user == nil {
+		return shareCopy
+	}
+	if existing := storedCopy(task, user); existing != nil {
+		return existing
+	}
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+	if index < 0 {
+		return shareCopy
+	}
+	user.ScheduledBlogs[index].Copy = shareCopy
+	if err := repo.UpdateUser(task.UserID, user); err != nil {
+		log.Printf("[WARN] Error saving the generated copy for blog id %s: %v", task.ScheduledBlog.Blog.Id, err)
+	}
+	return shareCopy
+}
+
+func (s *Scheduler) handleSuccess(task models.ScheduledBlogData, user *models.User, shareCopy *models.ShareCopy) {
+	blogId := task.ScheduledBlog.Blog.Id
+	services.RecordSharedBlog(user, shareCopy.Blog, task.ScheduledBlog.Platforms)
+
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+	if index < 0 {
+		log.Printf("[WARN] Blog with id %s not found in user's scheduled blogs", blogId)
+	}
+
+	// recurring schedules stay stored and go back on the queue for the next occurrence,
+	// unless the user cancelled the schedule while we were posting
+	if index >= 0 {
+		if next, ok := s.scheduleNextOccurrence(task, &user.ScheduledBlogs[index]); ok {
+			applyNextOccurrence(&user.ScheduledBlogs[index], next, fmt.Sprintf("shared at %s", s.clock.Now().Format(time.RFC3339)))
+			if updErr := repo.UpdateUser(task.UserID, user); updErr != nil {
+				log.Printf("[ERROR] Error updating user: %v", updErr)
+			}
+			log.Printf("[INFO] Task executed successfully for blog with ID %s and user ID %s at %v", blogId, task.UserID, task.ScheduledBlog.ScheduledTime)
+			return
+		}
+	}
+
+	delErr := repo.DeleteScheduledJob(task)
+	if delErr != nil {
+		log.Printf("[ERROR] Error deleting scheduled task: %v", delErr)
+	}
+
+	if index >= 0 {
+		updateTargets(&user.ScheduledBlogs[index], task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+			target.Status = models.TaskStatusShared
+			target.Result = ""
+			target.SharedAt = s.clock.Now()
+		})
+		settleScheduledBlog(user, index)
+	}
+
+	updErr := repo.UpdateUser(task.UserID, user)
+	if updErr != nil {
+		log.Printf("[ERROR] Error updating user: %v", updErr)
+	}
+
+	log.Printf("[INFO] Task executed successfully for blog with ID %s and user ID %s at %v", blogId, task.UserID, task.ScheduledBlog.ScheduledTime)
+}
+
+// handleFailure re-enqueues a failed share with an exponential backoff, once the task
+// runs out of attempts it is persisted as failed and the user gets notified.
+func (s *Scheduler) handleFailure(task models.ScheduledBlogData, user *models.User, shareCopy *models.ShareCopy, processErr error) {
+	blogId := task.ScheduledBlog.Blog.Id
+
+	task.Attempts++
+	task.LastError = processErr.Error()
+	if task.MaxAttempts <= 0 {
+		task.MaxAttempts = defaultMaxAttempts
+	}
+
+	// platforms that already went out before the failure shouldn't be posted again
+	var publishErr *services.PublishError
+	if errors.As(processErr, &publishErr) {
+		if len(publishErr.Published) > 0 && shareCopy != nil {
+			services.RecordSharedBlog(user, shareCopy.Blog, publishErr.Published)
+		}
+		task.ScheduledBlog.Platforms = remainingPlatforms(task.ScheduledBlog.Platforms, publishErr.Published)
+	}
+
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+	if index < 0 {
+		log.Printf("[WARN] Blog with id %s was cancelled while sharing, dropping the task", blogId)
+		if err := repo.DeleteScheduledJob(task); err != nil {
+			log.Printf("[ERROR] Error deleting scheduled task: %v", err)
+		}
+		if err := repo.UpdateUser(task.UserID, user); err != nil {
+			log.Printf("[ERROR] Error updating user: %v", err)
+		}
+		return
+	}
+	scheduled := &user.ScheduledBlogs[index]
+
+	if task.Attempts < task.MaxAttempts {
+		delay := retryDelay(task.Attempts)
+		task.NextAttemptAt = s.clock.Now().Add(delay)
+		if err := repo.UpdateScheduledTask(task); err != nil {
+			log.Printf("[ERROR] Error updating scheduled task for blog id %s: %v", blogId, err)
+		}
+		s.requeue(task)
+		updateTargets(scheduled, task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+			target.Result = fmt.Sprintf("attempt %d failed: %s", task.Attempts, task.LastError)
+		})
+		if err := repo.UpdateUser(task.UserID, user); err != nil {
+			log.Printf("[ERROR] Error updating user: %v", err)
+		}
+		log.Printf("[INFO] Retrying blog with ID %s for user ID %s in %v (attempt %d/%d)", blogId, task.UserID, delay, task.Attempts+1, task.MaxAttempts)
+		return
+	}
+
+	// a recurring schedule only gives up on this occurrence and moves on to the next one
+	if next, ok := s.scheduleNextOccurrence(task, scheduled); ok {
+		applyNextOccurrence(scheduled, next, task.LastError)
+		user.Notifications = append(user.Notifications, fmt.Sprintf("Failed to share the scheduled blog \"%s\" after %d attempts, it will be shared again at the next occurrence", task.ScheduledBlog.Title, task.Attempts))
+		if err := repo.UpdateUser(task.UserID, user); err != nil {
+			log.Printf("[ERROR] Error updating user: %v", err)
+		}
+		return
+	}
+
+	task.Status = models.TaskStatusFailed
+	task.NextAttemptAt = time.Time{}
+	if err := repo.UpdateScheduledTask(task); err != nil {
+		log.Printf("[ERROR] Error marking scheduled task as failed for blog id %s: %v", blogId, err)
+	}
+
+	if len(scheduled.Targets) == 0 {
+		scheduled.Status = models.TaskStatusFailed
+		scheduled.LastError = task.LastError
+	}
+	updateTargets(scheduled, task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+		target.Status = models.TaskStatusFailed
+		target.Result = task.LastError
+	})
+	settleScheduledBlog(user, index)
+
+	user.Notifications = append(user.Notifications, fmt.Sprintf("Failed to share the scheduled blog \"%s\" after %d attempts", task.ScheduledBlog.Title, task.Attempts))
+	if err := repo.UpdateUser(task.UserID, user); err != nil {
+		log.Printf("[ERROR] Error updating user: %v", err)
+	}
+	log.Printf("[INFO] Task failed permanently for blog with ID %s and user ID %s after %d attempts", blogId, task.UserID, task.Attempts)
+}
+
+// catchUpPolicy returns the policy of the scheduled blog, falling back to the user's.
+func catchUpPolicy(user *models.User, task models.ScheduledBlogData) *models.CatchUpPolicy {
+	if index := findScheduledBlog(user, task.ScheduledBlog.TaskKey()); index >= 0 && user.ScheduledBlogs[index].CatchUp != nil {
+		return user.ScheduledBlogs[index].CatchUp
+	}
+	if task.ScheduledBlog.CatchUp != nil {
+		return task.ScheduledBlog.CatchUp
+	}
+	return user.CatchUpPolicy
+}
+
+func notifyLateShare(task models.ScheduledBlogData, user *models.User, late time.Duration) {
+	log.Printf("[WARN] Blog with ID %s for user ID %s is shared %v late", task.ScheduledBlog.Blog.Id, task.UserID, late.Round(time.Second))
+	user.Notifications = append(user.Notifications, fmt.Sprintf("The scheduled blog \"%s\" was due on %s at %s and is being shared %v late", task.ScheduledBlog.Title, strings.Join(task.ScheduledBlog.Platforms, ", "), task.RunAt().Format(time.RFC3339), late.Round(time.Minute)))
+	if err := repo.UpdateUser(task.UserID, user); err != nil {
+		log.Printf("[ERROR] Error updating user: %v", err)
+	}
+}
+
+// skipMissedShare settles a share the catch up policy skipped, a recurring share moves
+// on to its next occurrence.
+func (s *Scheduler) skipMissedShare(task models.ScheduledBlogData, user *models.User, late time.Duration) {
+	blogId := task.ScheduledBlog.Blog.Id
+	log.Printf("[INFO] Skipping blog with ID %s for user ID %s, it is %v late", blogId, task.UserID, late.Round(time.Second))
+	result := fmt.Sprintf("skipped, it was due at %s", task.RunAt().Format(time.RFC3339))
+	user.Notifications = append(user.Notifications, fmt.Sprintf("Skipped sharing the scheduled blog \"%s\" on %s, it was due at %s and would have gone out %v late", task.ScheduledBlog.Title, strings.Join(task.ScheduledBlog.Platforms, ", "), task.RunAt().Format(time.RFC3339), late.Round(time.Minute)))
+
+	index := findScheduledBlog(user, task.ScheduledBlog.TaskKey())
+	if index >= 0 {
+		if next, ok := s.scheduleNextOccurrence(task, &user.ScheduledBlogs[index]); ok {
+			applyNextOccurrence(&user.ScheduledBlogs[index], next, result)
+			if err := repo.UpdateUser(task.UserID, user); err != nil {
+				log.Printf("[ERROR] Error updating user: %v", err)
+			}
+			return
+		}
+	}
+
+	if err := repo.DeleteScheduledJob(task); err != nil {
+		log.Printf("[ERROR] Error deleting scheduled task: %v", err)
+	}
+	if index >= 0 {
+		updateTargets(&user.ScheduledBlogs[index], task.ScheduledBlog.Platforms, func(target *models.PlatformTarget) {
+			target.Status = models.TaskStatusSkipped
+			target.Result = result
+		})
+		settleScheduledBlog(user, index)
+	}
+	if err := repo.UpdateUser(task.UserID, user); err != nil {
+		log.Printf("[ERROR] Error updating user: %v", err)
+	}
+}
+
+// recordSkippedRun records a share the catch up policy skipped, so the history shows
+// why it never went out.
+func recordSkippedRun(run *models.ShareRun, task models.ScheduledBlogData, late time.Duration) {
+	run.BlogTitle = task.ScheduledBlog.Title
+	outcomes := make([]models.PlatformOutcome, 0, len(task.ScheduledBlog.Platforms))
+	for _, platform := range task.ScheduledBlog.Platforms {
+		outcomes = append(outcomes, models.PlatformOutcome{Platform: platform, Status: models.TaskStatusSkipped})
+	}
+	services.FinishShareRun(run, nil, task.ScheduledBlog.Platforms, outcomes, fmt.Errorf("skipped by the catch up policy, it was %v late", late.Round(time.Second)))
+}
+
+// scheduleNextOccurrence moves a recurring task to its next occurrence, it updates the
+// stored task and puts it back on the queue. It returns false when the task doesn't
+// recur or the recurrence has ended.
+func (s *Scheduler) scheduleNextOccurrence(task models.ScheduledBlogData, scheduled *models.ScheduledBlog) (models.ScheduledBlogData, bool) {
+	if task.ScheduledBlog.Recurrence == nil {
+		return task, false
+	}
+	recurrence := *task.ScheduledBlog.Recurrence
+	recurrence.Occurrences++
+	// counted in the zone of the schedule, so a 09:00 share stays at 09:00 across DST
+	nextTime, ok := recurrence.Next(task.ScheduledBlog.ScheduledTime.In(task.ScheduledBlog.Location()))
+	if !ok {
+		return task, false
+	}
+	nextTime = nextTime.UTC()
+
+	next := task
+	// tasks from before per platform targets share to all platforms, the user's copy
+	// still has the ones retries trimmed off
+	if task.Platform == "" {
+		next.ScheduledBlog.Platforms = append([]string{}, scheduled.Platforms...)
+	}
+	next.ScheduledBlog.ScheduledTime = nextTime
+	next.ScheduledBlog.Recurrence = &recurrence
+	next.ScheduledBlog.Status = ""
+	next.ScheduledBlog.LastError = ""
+	next.Status = models.TaskStatusPending
+	next.Attempts = 0
+	next.NextAttemptAt = time.Time{}
+	next.LastError = ""
+	// a release only lets the held occurrence through
+	next.Approved = false
+	if err := repo.UpdateScheduledTask(next); err != nil {
+		log.Printf("[ERROR] Error updating recurring task for blog id %s: %v", next.ScheduledBlog.Id, err)
+	}
+
+	s.requeue(next)
+	log.Printf("[INFO] Next occurrence of blog with ID %s for user ID %s scheduled at %v", next.ScheduledBlog.Id, next.UserID, nextTime)
+	return next, true
+}
+
+// applyNextOccurrence moves the user's scheduled blog, or the target of the task, to
+// the next occurrence, result tells how the previous one went.
+func applyNextOccurrence(scheduled *models.ScheduledBlog, next models.ScheduledBlogData, result string) {
+	recurrence := *next.ScheduledBlog.Recurrence
+	scheduled.Recurrence = &recurrence
+
+	if len(scheduled.Targets) == 0 {
+		scheduled.ScheduledTime = next.ScheduledBlog.ScheduledTime
+		scheduled.Status = ""
+		scheduled.LastError = result
+		return
+	}
+	updateTargets(scheduled, []string{next.Platform}, func(target *models.PlatformTarget) {
+		target.ScheduledTime = next.ScheduledBlog.ScheduledTime
+		target.Status = ""
+		target.Result = result
+	})
+	syncScheduledTime(scheduled)
+}
+
+// settleScheduledBlog drops the user's scheduled blog once every target has gone out
+// or was skipped, when a target failed it stays around marked as failed so the user
+// can see it.
+func settleScheduledBlog(user *models.User, index int) {
+	scheduled := &user.ScheduledBlogs[index]
+
+	failed := scheduled.Status == models.TaskStatusFailed
+	for _, target := range scheduled.Targets {
+		switch target.Status {
+		case models.TaskStatusShared, models.TaskStatusSkipped:
+		case models.TaskStatusFailed:
+			failed = true
+			if scheduled.LastError == "" {
+				scheduled.LastError = target.Result
+			}
+		default:
+			syncScheduledTime(scheduled)
+			return
+		}
+	}
+
+	if failed {
+		scheduled.Status = models.TaskStatusFailed
+		return
+	}
+	user.ScheduledBlogs = append(user.ScheduledBlogs[:index], user.ScheduledBlogs[index+1:]...)
+}
+
+// syncScheduledTime keeps the blog's ScheduledTime at the earliest pending target.
+func syncScheduledTime(scheduled *models.ScheduledBlog) {
+	first := true
+	for _, target := range scheduled.Targets {
+		if target.Settled() {
+			continue
+		}
+		if first || target.ScheduledTime.Before(scheduled.ScheduledTime) {
+			scheduled.ScheduledTime = target.ScheduledTime
+			first = false
+		}
+	}
+}
+
+// updateTargets applies fn to the targets of the given platforms, schedules from
+// before per platform targets have none.
+func updateTargets(scheduled *models.ScheduledBlog, platforms []string, fn func(target *models.PlatformTarget)) {
+	for i := range scheduled.Targets {
+		for _, platform := range platforms {
+			if scheduled.Targets[i].Platform == platform {
+				fn(&scheduled.Targets[i])
+				break
+			}
+		}
+	}
+}
+
+// findScheduledBlog returns the index of the user's schedule with the given task key.
+func findScheduledBlog(user *models.User, taskKey string) int {
+	for i := range user.ScheduledBlogs {
+		if user.ScheduledBlogs[i].TaskKey() == taskKey {
+			return i
+		}
+	}
+	return -1
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
+
+func remainingPlatforms(platforms, published []string) []string {
+	done := make(map[string]bool, len(published))
+	for _, platform := range published {
+		done[platform] = true
+	}
+	var remaining []string
+	for _, platform := range platforms {
+		if !done[platform] {
+			remaining = append(remaining, platform)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"social-scribe/backend/internal/models"
+)
+
+var (
+	// ErrNoPostingSlots is returned when a blog is queued for a platform that has no
+	// posting slots.
+	ErrNoPostingSlots = errors.New("no posting slots")
+	// ErrQueueFull is returned when the next free slot is too far out to schedule.
+	ErrQueueFull = errors.New("queue is full")
+)
+
+// QueuedBlogs returns the user's queued schedules in the order they were queued.
+func QueuedBlogs(user *models.User) []models.ScheduledBlog {
+	var queued []models.ScheduledBlog
+	for _, blog := range user.ScheduledBlogs {
+		if blog.Queued {
+			queued = append(queued, blog)
+		}
+	}
+	sort.SliceStable(queued, func(i, j int) bool {
+		return queued[i].QueuedAt.Before(queued[j].QueuedAt)
+	})
+	return queued
+}
+
+// postingSchedule returns the user's posting slots, in the user's timezone unless the
+// slots have their own.
+func postingSchedule(user *models.User) *models.PostingSchedule {
+	if user.PostingSchedule == nil {
+		return nil
+	}
+	schedule := *user.PostingSchedule
+	if schedule.Timezone == "" {
+		schedule.Timezone = user.Timezone
+	}
+	return &schedule
+}
+
+// packQueue gives the pending targets of the queued schedules, in queue order, the next
+// free slot of their platform after now. Targets that are due already keep their time,
+// they are being shared, and so do held targets.
+func packQueue(schedule *models.PostingSchedule, queued []models.ScheduledBlog, now time.Time) ([]models.ScheduledBlog, error) {
+	cursor := map[string]time.Time{}
+	packed := make([]models.ScheduledBlog, 0, len(queued))
+	for _, blog := range queued {
+		blog.Targets = append([]models.PlatformTarget{}, blog.Targets...)
+		for i := range blog.Targets {
+			target := &blog.Targets[i]
+			if target.Settled() || target.Status == models.TaskStatusHeld || (!target.ScheduledTime.IsZero() && !target.ScheduledTime.After(now)) {
+				continue
+			}
+			after, ok := cursor[target.Platform]
+			if !ok {
+				after = now
+			}
+			slot, ok := schedule.NextSlot(target.Platform, after)
+			if !ok {
+				return nil, fmt.Errorf("%w for %s", ErrNoPostingSlots, target.Platform)
+			}
+			target.ScheduledTime = slot
+			cursor[target.Platform] = slot
+		}
+		blog.NormalizeTargets()
+		packed = append(packed, blog)
+	}
+	return packed, nil
+}
+
+// NextFreeSlots returns the first slot of every platform of the user's posting schedule
+// that no queued blog takes.
+func NextFreeSlots(user *models.User, now time.Time) map[string]time.Time {
+	cursor := map[string]time.Time{}
+	for _, blog := range QueuedBlogs(user) {
+		for _, target := range blog.Targets {
+			if !target.Settled() && target.ScheduledTime.After(cursor[target.Platform]) {
+				cursor[target.Platform] = target.ScheduledTime
+			}
+		}
+	}
+
+	free := map[string]time.Time{}
+	schedule := postingSchedule(user)
+	if schedule == nil {
+		return free
+	}
+	for _, slot := range schedule.Slots {
+		if _, done := free[slot.Platform]; done {
+			continue
+		}
+		after := now
+		if cursor[slot.Platform].After(after) {
+			after = cursor[slot.Platform]
+		}
+		if next, ok := schedule.NextSlot(slot.Platform, after); ok {
+			free[slot.Platform] = next
+		}
+	}
+	return free
+}
+
+// QueueBlog appends the blog to the user's queue, every platform goes out in the next
+// free posting slot of that platform. The tasks are added to the scheduler, adding the
+// blog to the user is left to the caller.
+func (s *Scheduler) QueueBlog(userId string, user *models.User, blog models.ScheduledBlog) (models.ScheduledBlog, error) {
+	if blog.Recurrence != nil {
+		return blog, fmt.Errorf("queued blogs can't recur")
+	}
+	blog.Queued = true
+	blog.QueuedAt = s.clock.Now()
+	blog.Targets = nil
+	for _, platform := range blog.Platforms {
+		blog.Targets = append(blog.Targets, models.PlatformTarget{Platform: platform})
+	}
+
+	packed, err := packQueue(postingSchedule(user), append(QueuedBlogs(user), blog), blog.QueuedAt)
+	if err != nil {
+		return blog, err
+	}
+	blog = packed[len(packed)-1]
+	if err := blog.Validate(s.clock); err != nil {
+		return blog, fmt.Errorf("%w: %v", ErrQueueFull, err)
+	}
+	if err := s.AddScheduledBlog(userId, blog); err != nil {
+		return blog, err
+	}
+	return blog, nil
+}
+
+// RepackQueue moves the user's queued blogs up into the slots that freed up, after a
+// queued blog was cancelled or the posting slots changed. A blog that is being shared
+// right now keeps its slot. The moved blogs are saved and updated on user as well, when
+// a platform of the queue has no slots nothing changes and ErrNoPostingSlots is returned.
+func (s *Scheduler) RepackQueue(userId string, user *models.User) error {
+	queued := QueuedBlogs(user)
+	packed, err := packQueue(postingSchedule(user), queued, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	for i, updated := range packed {
+		if !targetTimesChanged(queued[i], updated) {
+			continue
+		}
+		err := s.RescheduleBlog(userId, queued[i], updated)
+		if errors.Is(err, ErrTargetRunning) {
+			log.Printf("[INFO] Queued blog %s is being shared, leaving it in its slot", updated.TaskKey())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for j := range user.ScheduledBlogs {
+			if user.ScheduledBlogs[j].TaskKey() == updated.TaskKey() {
+				user.ScheduledBlogs[j] = updated
+			}
+		}
+	}
+	return nil
+}
+
+func targetTimesChanged(old, updated models.ScheduledBlog) bool {
+	if len(old.Targets) != len(updated.Targets) {
+		return true
+	}
+	for i := range old.Targets {
+		if !old.Targets[i].ScheduledTime.Equal(updated.Targets[i].ScheduledTime) {
+			return true
+		}
+	}
+	return false
+}
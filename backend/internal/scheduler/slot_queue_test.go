@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+func queueUser() *models.User {
+	return &models.User{
+		PostingSchedule: &models.PostingSchedule{
+			Timezone: "UTC",
+			Slots: []models.PostingSlot{
+				{Platform: "twitter", Weekday: "tue", Time: "09:30"},
+				{Platform: "twitter", Weekday: "thu", Time: "09:30"},
+			},
+		},
+	}
+}
+
+func queuedBlog(blogId string) models.ScheduledBlog {
+	blog := models.ScheduledBlog{TaskId: models.NewTaskId(), Platforms: []string{"twitter"}}
+	blog.Id = blogId
+	blog.Title = "A blog"
+	blog.Url = "https://blog.example.com/" + blogId
+	blog.CoverImage.URL = "https://cdn.example.com/cover.png"
+	blog.Author.Name = "Author"
+	return blog
+}
+
+func TestQueueBlog_FillsSlotsInOrder(t *testing.T) {
+	s := newTestScheduler(t)
+	user := queueUser()
+
+	var times []time.Time
+	for _, blogId := range []string{"blog1", "blog2", "blog3"} {
+		blog, err := s.QueueBlog("user1", user, queuedBlog(blogId))
+		assert.NoError(t, err)
+		assert.True(t, blog.Queued)
+		user.ScheduledBlogs = append(user.ScheduledBlogs, blog)
+		times = append(times, blog.ScheduledTime)
+	}
+
+	expected, _ := user.PostingSchedule.NextSlot("twitter", time.Now())
+	for _, at := range times {
+		assert.Equal(t, expected, at)
+		expected, _ = user.PostingSchedule.NextSlot("twitter", expected)
+	}
+	assert.Equal(t, expected, NextFreeSlots(user, time.Now())["twitter"])
+
+	_, err := s.QueueBlog("user1", user, func() models.ScheduledBlog {
+		blog := queuedBlog("blog4")
+		blog.Platforms = []string{"linkedin"}
+		return blog
+	}())
+	assert.ErrorIs(t, err, ErrNoPostingSlots)
+}
+
+func TestRepackQueue_MovesBlogsUp(t *testing.T) {
+	s := newTestScheduler(t)
+	user := queueUser()
+	for _, blogId := range []string{"blog1", "blog2", "blog3"} {
+		blog, err := s.QueueBlog("user1", user, queuedBlog(blogId))
+		assert.NoError(t, err)
+		user.ScheduledBlogs = append(user.ScheduledBlogs, blog)
+	}
+	first, second := user.ScheduledBlogs[0].ScheduledTime, user.ScheduledBlogs[1].ScheduledTime
+
+	var saved []models.ScheduledBlog
+	repo.SaveRescheduledBlog = func(userId string, blog models.ScheduledBlog, tasks []models.ScheduledBlogData, deleted []models.Job) error {
+		saved = append(saved, blog)
+		return nil
+	}
+	repo.DeleteScheduledJob = func(job models.Job) error {
+		return nil
+	}
+	assert.NoError(t, s.RemoveTask(user.ScheduledBlogs[0]))
+	user.ScheduledBlogs = user.ScheduledBlogs[1:]
+
+	assert.NoError(t, s.RepackQueue("user1", user))
+	assert.Len(t, saved, 2)
+	assert.Equal(t, first, user.ScheduledBlogs[0].ScheduledTime)
+	assert.Equal(t, second, user.ScheduledBlogs[1].ScheduledTime)
+
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	for _, job := range pending {
+		if job.Key() == models.ShareJobKey(user.ScheduledBlogs[0].TaskId, "twitter") {
+			assert.Equal(t, first, job.RunAt())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+const defaultStorePollInterval = 30 * time.Second
+
+// storeWatcher keeps the queue in sync with scheduled_items for jobs other processes
+// write there. It follows the collection's change stream, and falls back to polling
+// on a standalone MongoDB where change streams aren't available.
+type storeWatcher struct {
+	s            *Scheduler
+	pollInterval time.Duration
+
+	// keys maps the id of every stored document the watcher knows about to its job
+	// key, deletes only carry the id
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+// watchStoreFromEnv starts the store watcher when SCHEDULER_WATCH_STORE is set.
+func (s *Scheduler) watchStoreFromEnv() {
+	if os.Getenv("SCHEDULER_WATCH_STORE") != "true" {
+		return
+	}
+	interval, err := time.ParseDuration(os.Getenv("SCHEDULER_WATCH_POLL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = defaultStorePollInterval
+	}
+	w := &storeWatcher{s: s, pollInterval: interval, keys: make(map[string]string)}
+	go w.run()
+}
+
+func (w *storeWatcher) run() {
+	log.Println("[INFO] Watching scheduled_items for jobs written by other processes")
+	for {
+		// a fresh stream doesn't replay what happened before it was opened, so every
+		// (re)start catches up with a poll first
+		w.poll()
+		err := repo.WatchScheduledJobs(w.s.ctx, w.apply)
+		if w.s.ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, repo.ErrChangeStreamsUnsupported) {
+			log.Printf("[WARN] MongoDB doesn't support change streams, polling scheduled_items every %v instead", w.pollInterval)
+			w.pollEvery()
+			return
+		}
+		log.Printf("[ERROR] Watching scheduled_items stopped, restarting in %v: %v", w.pollInterval, err)
+		if !w.wait() {
+			return
+		}
+	}
+}
+
+func (w *storeWatcher) pollEvery() {
+	for w.wait() {
+		w.poll()
+	}
+}
+
+// wait sleeps for the poll interval, it returns false when the scheduler stopped.
+func (w *storeWatcher) wait() bool {
+	timer := w.s.clock.NewTimer(w.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-w.s.ctx.Done():
+		return false
+	}
+}
+
+// poll picks up the documents written by others since the last poll, and drops the
+// jobs of the documents that are gone.
+func (w *storeWatcher) poll() {
+	changes, err := repo.GetUnsyncedJobs()
+	if err != nil {
+		return
+	}
+	for _, change := range changes {
+		w.apply(change, false)
+	}
+
+	stored, err := repo.GetStoredJobKeys()
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	var deleted []string
+	for id := range w.keys {
+		if _, ok := stored[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	for id, key := range stored {
+		w.keys[id] = key
+	}
+	w.mu.Unlock()
+	for _, id := range deleted {
+		w.apply(repo.ScheduledJobChange{Id: id}, false)
+	}
+}
+
+// apply brings a change into the queue. Changes the scheduler made itself only teach
+// the watcher the key of the document, the queue already has them.
+func (w *storeWatcher) apply(change repo.ScheduledJobChange, ours bool) {
+	w.mu.Lock()
+	if change.Job == nil {
+		key, ok := w.keys[change.Id]
+		delete(w.keys, change.Id)
+		w.mu.Unlock()
+		if !ok {
+			return
+		}
+		// the scheduler deletes the documents of jobs it finished or removed, those
+		// aren't on the queue anymore and this is a no-op
+		if _, err := w.s.queue.Remove(key); err != nil {
+			log.Printf("[ERROR] Error removing job %s deleted from the store: %v", key, err)
+		}
+		return
+	}
+	key := change.Job.Key()
+	w.keys[change.Id] = key
+	w.mu.Unlock()
+	if ours {
+		return
+	}
+
+	if !change.Runnable {
+		if _, err := w.s.queue.Remove(key); err != nil {
+			log.Printf("[ERROR] Error removing job %s stopped in the store: %v", key, err)
+		}
+		return
+	}
+	// the running job writes its own outcome, which wins over this change
+	if w.s.pool.Has(key) {
+		log.Printf("[WARN] Job %s changed in the store while it runs, ignoring the change", key)
+		return
+	}
+	previous, err := w.s.queue.Update(change.Job)
+	if err == nil && previous == nil {
+		// Load leaves alone a job another replica claimed
+		err = w.s.queue.Load([]models.Job{change.Job})
+	}
+	if err != nil {
+		log.Printf("[ERROR] Error queueing job %s from the store: %v", key, err)
+		return
+	}
+	log.Printf("[INFO] Picked up %s job %s from the store, runs at %v", change.Job.Kind(), key, change.Job.RunAt())
+	w.s.notify()
+}
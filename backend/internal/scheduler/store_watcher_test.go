@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"social-scribe/backend/internal/models"
+	repo "social-scribe/backend/internal/repositories"
+)
+
+func newTestStoreWatcher(t *testing.T) (*Scheduler, *storeWatcher) {
+	s := newTestScheduler(t)
+	return s, &storeWatcher{s: s, pollInterval: time.Minute, keys: make(map[string]string)}
+}
+
+func TestStoreWatcher_AppliesOutsideWrites(t *testing.T) {
+	s, w := newTestStoreWatcher(t)
+	at := time.Now().Add(time.Hour)
+
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: true}, false)
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// an outside update moves the pending job
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", at.Add(time.Hour)), Runnable: true}, false)
+	next, _, err := s.queue.NextRunAt()
+	assert.NoError(t, err)
+	assert.Equal(t, at.Add(time.Hour), next)
+
+	// held in the store, so it leaves the queue
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: false}, false)
+	pending, err = s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: true}, false)
+	w.apply(repo.ScheduledJobChange{Id: "doc1"}, false)
+	pending, err = s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestStoreWatcher_IgnoresOwnWrites(t *testing.T) {
+	s, w := newTestStoreWatcher(t)
+	at := time.Now().Add(time.Hour)
+
+	// the scheduler's own insert of a job that already ran must not bring it back
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: true}, true)
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Equal(t, "blog1", w.keys["doc1"])
+
+	// deletes of unknown documents are left alone
+	assert.NoError(t, s.queue.Push(testTask("user1", "blog2", at)))
+	w.apply(repo.ScheduledJobChange{Id: "doc2"}, false)
+	pending, err = s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
+
+func TestStoreWatcher_SkipsRunningJob(t *testing.T) {
+	s, w := newTestStoreWatcher(t)
+	release := make(chan struct{})
+	defer close(release)
+	s.RegisterHandler(models.JobKindShareBlog, func(job models.Job) error {
+		<-release
+		return nil
+	})
+	assert.NoError(t, s.AddJob(testTask("user1", "blog1", time.Now())))
+	assert.Eventually(t, func() bool { return s.pool.Has("blog1") }, time.Second, 5*time.Millisecond)
+
+	w.apply(repo.ScheduledJobChange{Id: "doc1", Job: testTask("user1", "blog1", time.Now()), Runnable: true}, false)
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestStoreWatcher_Poll(t *testing.T) {
+	s, w := newTestStoreWatcher(t)
+	at := time.Now().Add(time.Hour)
+
+	stored := map[string]string{"doc1": "blog1", "doc2": "blog2"}
+	repo.GetUnsyncedJobs = func() ([]repo.ScheduledJobChange, error) {
+		return []repo.ScheduledJobChange{{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: true}}, nil
+	}
+	repo.GetStoredJobKeys = func() (map[string]string, error) {
+		return stored, nil
+	}
+	w.poll()
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// doc1 was deleted since the last poll
+	repo.GetUnsyncedJobs = func() ([]repo.ScheduledJobChange, error) {
+		return nil, nil
+	}
+	stored = map[string]string{"doc2": "blog2"}
+	w.poll()
+	pending, err = s.queue.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Equal(t, map[string]string{"doc2": "blog2"}, w.keys)
+}
+
+func TestStoreWatcher_PollPicksUpUpdates(t *testing.T) {
+	s, w := newTestStoreWatcher(t)
+	at := time.Now().Add(time.Hour)
+
+	repo.GetUnsyncedJobs = func() ([]repo.ScheduledJobChange, error) {
+		return []repo.ScheduledJobChange{{Id: "doc1", Job: testTask("user1", "blog1", at), Runnable: true}}, nil
+	}
+	repo.GetStoredJobKeys = func() (map[string]string, error) {
+		return map[string]string{"doc1": "blog1"}, nil
+	}
+	w.poll()
+
+	// an admin script moved the stored job, the document was already synced
+	repo.GetUnsyncedJobs = func() ([]repo.ScheduledJobChange, error) {
+		return []repo.ScheduledJobChange{{Id: "doc1", Job: testTask("user1", "blog1", at.Add(time.Hour)), Runnable: true}}, nil
+	}
+	w.poll()
+	pending, err := s.queue.Pending()
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, at.Add(time.Hour), pending[0].RunAt())
+	}
+}
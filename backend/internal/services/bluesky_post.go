@@ -0,0 +1,327 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	// BlueskyMaxGraphemes is the longest post Bluesky takes, counted in graphemes
+	BlueskyMaxGraphemes   = 300
+	defaultBlueskyService = "bsky.social"
+	// blobs larger than this are refused by the PDS, the card goes out without a thumb
+	blueskyMaxThumbBytes = 1000000
+)
+
+var (
+	blueskyLinkPattern = regexp.MustCompile(`https?://[^\s]+`)
+	blueskyTagPattern  = regexp.MustCompile(`(^|\s)(#[\p{L}\p{N}_]+)`)
+)
+
+// BlueskySession is what signing in with an app password gives back.
+type BlueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	Did       string `json:"did"`
+	Handle    string `json:"handle"`
+}
+
+// NormalizeBlueskyService turns the PDS the user typed into its host, bsky.social
+// when they left it empty.
+func NormalizeBlueskyService(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultBlueskyService, nil
+	}
+	host, err := normalizeHost(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid Bluesky service")
+	}
+	return host, nil
+}
+
+// CreateBlueskySession signs in to the PDS with the handle and an app password.
+func CreateBlueskySession(service, handle, appPassword string) (*BlueskySession, error) {
+	body, err := json.Marshal(map[string]string{
+		"identifier": strings.TrimPrefix(strings.TrimSpace(handle), "@"),
+		"password":   appPassword,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Post(blueskyXrpcURL(service, "com.atproto.server.createSession"), "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create session, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	var session BlueskySession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %v", err)
+	}
+	return &session, nil
+}
+
+func blueskyXrpcURL(service, method string) string {
+	if service == "" {
+		service = defaultBlueskyService
+	}
+	return "https://" + service + "/xrpc/" + method
+}
+
+// graphemeCount counts user perceived characters the way Bluesky limits posts. It is
+// an approximation of the Unicode segmentation rules: combining marks, joiners,
+// variation selectors and skin tones stay with the character before them and flags
+// count once.
+func graphemeCount(text string) int {
+	return len(graphemeBounds(text))
+}
+
+// graphemeBounds returns the byte offset where every grapheme of the text starts.
+func graphemeBounds(text string) []int {
+	var bounds []int
+	joined := false
+	regionalOpen := false
+	for i, r := range text {
+		extends := unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Mc, r) ||
+			(r >= 0xFE00 && r <= 0xFE0F) || (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F) ||
+			r == 0x200D || joined
+		regional := r >= 0x1F1E6 && r <= 0x1F1FF
+		if regional && regionalOpen {
+			extends = true
+		}
+		if !extends || len(bounds) == 0 {
+			bounds = append(bounds, i)
+		}
+		joined = r == 0x200D
+		regionalOpen = regional && !regionalOpen
+	}
+	return bounds
+}
+
+// trimGraphemes cuts the text at the last word that fits in the limit.
+func trimGraphemes(text string, limit int) string {
+	bounds := graphemeBounds(text)
+	if len(bounds) <= limit {
+		return text
+	}
+	cut := text[:bounds[limit-1]]
+	if space := strings.LastIndexAny(cut, " \n"); space > 0 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " \n") + "…"
+}
+
+// blueskyFacets marks the links and hashtags of the text, Bluesky renders neither
+// without them. Offsets are in bytes of the UTF-8 text.
+func blueskyFacets(text string) []map[string]interface{} {
+	var facets []map[string]interface{}
+	for _, match := range blueskyLinkPattern.FindAllStringIndex(text, -1) {
+		link := strings.TrimRight(text[match[0]:match[1]], ".,;:!?)\"'")
+		facets = append(facets, blueskyFacet(match[0], match[0]+len(link), map[string]interface{}{
+			"$type": "app.bsky.richtext.facet#link",
+			"uri":   link,
+		}))
+	}
+	for _, match := range blueskyTagPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[4], match[5]
+		facets = append(facets, blueskyFacet(start, end, map[string]interface{}{
+			"$type": "app.bsky.richtext.facet#tag",
+			"tag":   text[start+1 : end],
+		}))
+	}
+	return facets
+}
+
+func blueskyFacet(start, end int, feature map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"index": map[string]int{
+			"byteStart": start,
+			"byteEnd":   end,
+		},
+		"features": []map[string]interface{}{feature},
+	}
+}
+
+// blueskyEmbed is the link card of the blog. A cover image that can't be uploaded
+// leaves the card without a thumb rather than failing the post.
+func blueskyEmbed(service, accessJwt string, blog models.Blog) map[string]interface{} {
+	description := blog.Author.Name
+	if blog.ReadTimeInMinutes > 0 {
+		description = fmt.Sprintf("%s · %d min read", blog.Author.Name, blog.ReadTimeInMinutes)
+	}
+	external := map[string]interface{}{
+		"uri":         blog.Url,
+		"title":       blog.Title,
+		"description": strings.TrimPrefix(description, " · "),
+	}
+	if blog.CoverImage.URL != "" {
+		thumb, err := uploadBlueskyThumb(service, accessJwt, blog.CoverImage.URL)
+		if err != nil {
+			log.Printf("[WARN] Posting the Bluesky card of blog %s without a thumb: %v", blog.Id, err)
+		} else {
+			external["thumb"] = thumb
+		}
+	}
+	return map[string]interface{}{
+		"$type":    "app.bsky.embed.external",
+		"external": external,
+	}
+}
+
+func uploadBlueskyThumb(service, accessJwt, imageURL string) (json.RawMessage, error) {
+	image, contentType, err := fetchCoverImage(imageURL, blueskyMaxThumbBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", blueskyXrpcURL(service, "com.atproto.repo.uploadBlob"), bytes.NewReader(image))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessJwt)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload cover image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to upload cover image, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	var uploaded struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil || len(uploaded.Blob) == 0 {
+		return nil, fmt.Errorf("failed to parse uploaded blob: %v", err)
+	}
+	return uploaded.Blob, nil
+}
+
+// postBluesky creates the post record with the card of the blog and returns its
+// at:// uri.
+func postBluesky(service string, session *BlueskySession, text string, blog models.Blog) (string, error) {
+	text = trimGraphemes(text, BlueskyMaxGraphemes)
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"langs":     []string{"en"},
+	}
+	if facets := blueskyFacets(text); len(facets) > 0 {
+		record["facets"] = facets
+	}
+	if blog.Url != "" {
+		record["embed"] = blueskyEmbed(service, session.AccessJwt, blog)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"repo":       session.Did,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post: %v", err)
+	}
+	req, err := http.NewRequest("POST", blueskyXrpcURL(service, "com.atproto.repo.createRecord"), bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send post: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to create post, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	var created struct {
+		Uri string `json:"uri"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Uri, nil
+}
+
+// deleteBluesky deletes the post with the at:// uri postBluesky returned.
+func deleteBluesky(service string, session *BlueskySession, postUri string) error {
+	rkey := postUri[strings.LastIndex(postUri, "/")+1:]
+	if rkey == "" {
+		return fmt.Errorf("invalid post uri %s", postUri)
+	}
+	body, err := json.Marshal(map[string]string{
+		"repo":       session.Did,
+		"collection": "app.bsky.feed.post",
+		"rkey":       rkey,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", blueskyXrpcURL(service, "com.atproto.repo.deleteRecord"), bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send delete request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete post, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+type blueskyPublisher struct{}
+
+func init() {
+	RegisterPublisher(blueskyPublisher{})
+}
+
+func (blueskyPublisher) Name() string        { return "bluesky" }
+func (blueskyPublisher) DisplayName() string { return "Bluesky" }
+func (blueskyPublisher) MaxChars() int       { return BlueskyMaxGraphemes }
+
+func (blueskyPublisher) Capabilities() Capabilities {
+	return Capabilities{Delete: true, Images: true}
+}
+
+func (blueskyPublisher) HasCredentials(user *models.User) bool {
+	return user.BlueskyVerified && user.BlueskyHandle != "" && user.BlueskyAppPassword != ""
+}
+
+func (blueskyPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	session, err := CreateBlueskySession(user.BlueskyService, user.BlueskyHandle, user.BlueskyAppPassword)
+	if err != nil {
+		return "", err
+	}
+	return postBluesky(user.BlueskyService, session, shareCopy.Posts["bluesky"], shareCopy.Blog)
+}
+
+func (blueskyPublisher) Delete(user *models.User, postId string) error {
+	session, err := CreateBlueskySession(user.BlueskyService, user.BlueskyHandle, user.BlueskyAppPassword)
+	if err != nil {
+		return err
+	}
+	return deleteBluesky(user.BlueskyService, session, postId)
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// coverImageMaxBytes is the largest image X and LinkedIn both take in a post
+const coverImageMaxBytes = 5 * 1024 * 1024
+
+// fetchCoverImage downloads the blog's cover image and returns it with its content
+// type. Anything that isn't an image or is larger than maxBytes is refused.
+func fetchCoverImage(imageURL string, maxBytes int) ([]byte, string, error) {
+	resp, err := httpClient.Get(imageURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch cover image: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch cover image, status code: %d", resp.StatusCode)
+	}
+	image, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read cover image: %v", err)
+	}
+	if len(image) > maxBytes {
+		return nil, "", fmt.Errorf("cover image is larger than %d bytes", maxBytes)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(image)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("cover image is %s, not an image", contentType)
+	}
+	return image, contentType, nil
+}
+
+// altText is the description of the cover image, the title of the blog it heads.
+func altText(title string, limit int) string {
+	if runes := []rune(title); len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return title
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-scribe/backend/internal/models"
+	"social-scribe/backend/internal/repositories"
+)
+
+const (
+	devtoAPI = "https://dev.to/api"
+	// devtoMaxTags is how many tags an article takes
+	devtoMaxTags = 4
+)
+
+var (
+	// Hashnode keeps image attributes in the markdown, ![](url align="center"), which
+	// other renderers show as part of the url
+	hashnodeImageAttrPattern = regexp.MustCompile(`(!\[[^\]]*\]\([^)\s]+)(?:\s+[a-z]+="[^"]*")+\)`)
+	devtoTagPattern          = regexp.MustCompile(`[^a-z0-9]`)
+)
+
+// VerifyDevtoKey checks the API key and returns the username of its account.
+func VerifyDevtoKey(apiKey string) (string, error) {
+	req, err := http.NewRequest("GET", devtoAPI+"/users/me", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("api-key", apiKey)
+	req.Header.Set("Accept", "application/vnd.forem.api-v1+json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to verify Dev.to key, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	var account struct {
+		Username string `json:"username"`
+	}
+	json.NewDecoder(resp.Body).Decode(&account)
+	return account.Username, nil
+}
+
+// devtoMarkdown is the Hashnode markdown with what Dev.to doesn't render taken out.
+func devtoMarkdown(markdown string) string {
+	return hashnodeImageAttrPattern.ReplaceAllString(markdown, "$1)")
+}
+
+// devtoTags turns Hashnode tag slugs into Dev.to tags, which are alphanumeric only.
+func devtoTags(post *hashnodePost) []string {
+	var tags []string
+	for _, tag := range post.Tags {
+		name := devtoTagPattern.ReplaceAllString(strings.ToLower(tag.Slug), "")
+		if name == "" || containsPlatform(tags, name) {
+			continue
+		}
+		tags = append(tags, name)
+		if len(tags) == devtoMaxTags {
+			break
+		}
+	}
+	return tags
+}
+
+// devtoArticle is the article of the post, canonical_url points back at Hashnode so
+// search engines credit the original.
+func devtoArticle(post *hashnodePost, published bool) map[string]interface{} {
+	article := map[string]interface{}{
+		"title":         post.Title,
+		"body_markdown": devtoMarkdown(post.Content.Markdown),
+		"published":     published,
+		"canonical_url": post.Url,
+		"tags":          devtoTags(post),
+	}
+	if post.Brief != "" {
+		article["description"] = post.Brief
+	}
+	if post.CoverImage.Url != "" {
+		article["main_image"] = post.CoverImage.Url
+	}
+	return map[string]interface{}{"article": article}
+}
+
+// sendDevtoArticle creates the article, or updates it when it has an id already.
+func sendDevtoArticle(apiKey, articleId string, payload map[string]interface{}) (string, string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal article: %v", err)
+	}
+	method, endpoint := "POST", devtoAPI+"/articles"
+	if articleId != "" {
+		method, endpoint = "PUT", devtoAPI+"/articles/"+articleId
+	}
+	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("api-key", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.forem.api-v1+json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to send article: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", "", fmt.Errorf("failed to send article, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	var article struct {
+		Id  int64  `json:"id"`
+		Url string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&article); err != nil {
+		return "", "", fmt.Errorf("failed to parse article: %v", err)
+	}
+	return strconv.FormatInt(article.Id, 10), article.Url, nil
+}
+
+// CrossPostToDevto republishes the full blog on Dev.to. The first call creates the
+// article, later ones update it with the current content of the blog and do nothing
+// when neither it nor published changed.
+func CrossPostToDevto(user *models.User, blogId string, published bool) (*models.CrossPost, error) {
+	if !user.DevtoVerified || user.DevtoApiKey == "" {
+		return nil, fmt.Errorf("Dev.to is not connected")
+	}
+	userId := user.Id.Hex()
+	crossPost, err := repositories.GetCrossPost(userId, blogId, models.CrossPostDevto)
+	if err != nil {
+		return nil, err
+	}
+	post, err := fetchHashnodePost(blogId)
+	if err != nil {
+		return nil, err
+	}
+	if post.Content.Markdown == "" {
+		return nil, fmt.Errorf("blog %s has no content to cross post", blogId)
+	}
+
+	payload := devtoArticle(post, published)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal article: %v", err)
+	}
+	contentHash := hashText(string(body))
+	if crossPost != nil && crossPost.ContentHash == contentHash {
+		return crossPost, nil
+	}
+
+	now := time.Now()
+	if crossPost == nil {
+		crossPost = &models.CrossPost{UserId: userId, BlogId: blogId, Platform: models.CrossPostDevto, CreatedAt: now}
+	}
+	remoteId, remoteUrl, err := sendDevtoArticle(user.DevtoApiKey, crossPost.RemoteId, payload)
+	if err != nil {
+		return nil, err
+	}
+	crossPost.RemoteId = remoteId
+	crossPost.RemoteUrl = remoteUrl
+	crossPost.Published = published
+	crossPost.ContentHash = contentHash
+	crossPost.UpdatedAt = now
+	if err := repositories.SaveCrossPost(crossPost); err != nil {
+		// the article is out there, without its id the next sync would post it again
+		return crossPost, fmt.Errorf("cross posted to %s but failed to save it: %v", remoteUrl, err)
+	}
+	return crossPost, nil
+}
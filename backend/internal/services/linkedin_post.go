@@ -5,25 +5,50 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
+
+	"social-scribe/backend/internal/models"
 )
 
-func linkedPostHandler(message, accessToken string) error {
+// linkedinMaxAltText is the longest image description LinkedIn shows
+const linkedinMaxAltText = 300
+
+// linkedPostHandler posts the message with the blog's cover image and returns the URN
+// of the new post. The post goes out text-only when the image can't be uploaded.
+func linkedPostHandler(message, accessToken string, blog models.Blog) (string, error) {
 	userURN, err := getUserURN(accessToken)
 	if err != nil {
-		return fmt.Errorf("failed to fetch user ID: %v", err)
+		return "", fmt.Errorf("failed to fetch user ID: %v", err)
+	}
+
+	shareContent := map[string]interface{}{
+		"shareCommentary": map[string]interface{}{
+			"text": message,
+		},
+		"shareMediaCategory": "NONE",
+	}
+	if blog.CoverImage.URL != "" {
+		asset, err := uploadLinkedinImage(accessToken, userURN, blog.CoverImage.URL)
+		if err != nil {
+			log.Printf("[WARN] Sharing blog id %s on LinkedIn without its cover image: %v", blog.Id, err)
+		} else {
+			shareContent["shareMediaCategory"] = "IMAGE"
+			shareContent["media"] = []map[string]interface{}{{
+				"status":      "READY",
+				"media":       asset,
+				"title":       map[string]string{"text": blog.Title},
+				"description": map[string]string{"text": altText(blog.Title, linkedinMaxAltText)},
+			}}
+		}
 	}
 
 	postData := map[string]interface{}{
 		"author":         userURN,
 		"lifecycleState": "PUBLISHED",
 		"specificContent": map[string]interface{}{
-			"com.linkedin.ugc.ShareContent": map[string]interface{}{
-				"shareCommentary": map[string]interface{}{
-					"text": message,
-				},
-				"shareMediaCategory": "NONE",
-			},
+			"com.linkedin.ugc.ShareContent": shareContent,
 		},
 		"visibility": map[string]interface{}{
 			"com.linkedin.ugc.MemberNetworkVisibility": "PUBLIC",
@@ -32,33 +57,136 @@ func linkedPostHandler(message, accessToken string) error {
 
 	postBody, err := json.Marshal(postData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal post data: %v", err)
+		return "", fmt.Errorf("failed to marshal post data: %v", err)
 	}
 
 	req, err := http.NewRequest("POST", "https://api.linkedin.com/v2/ugcPosts", bytes.NewBuffer(postBody))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+		return "", fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Restli-Protocol-Version", "2.0.0")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send post request: %v", err)
+		return "", fmt.Errorf("failed to send post request: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to create post, status code: %d, response: %s", resp.StatusCode, body)
+		return "", fmt.Errorf("failed to create post, status code: %d, response: %s", resp.StatusCode, body)
+	}
+
+	// the id of the new post comes back in a header, older API versions put it in the body
+	if postId := resp.Header.Get("X-RestLi-Id"); postId != "" {
+		return postId, nil
+	}
+	var created struct {
+		Id string `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Id, nil
+}
+
+// linkedinDeleteHandler deletes the post with the URN linkedPostHandler returned.
+func linkedinDeleteHandler(postURN, accessToken string) error {
+	req, err := http.NewRequest("DELETE", "https://api.linkedin.com/v2/ugcPosts/"+url.PathEscape(postURN), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("X-Restli-Protocol-Version", "2.0.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send delete request: %v", err)
 	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete post, status code: %d, response: %s", resp.StatusCode, body)
+	}
 	return nil
 }
 
+// uploadLinkedinImage registers an upload of a feed image, uploads the image and
+// returns the URN of the asset a post attaches it with.
+func uploadLinkedinImage(accessToken, ownerURN, imageURL string) (string, error) {
+	image, contentType, err := fetchCoverImage(imageURL, coverImageMaxBytes)
+	if err != nil {
+		return "", err
+	}
+
+	registerBody, err := json.Marshal(map[string]interface{}{
+		"registerUploadRequest": map[string]interface{}{
+			"recipes": []string{"urn:li:digitalmediaRecipe:feedshare-image"},
+			"owner":   ownerURN,
+			"serviceRelationships": []map[string]string{{
+				"relationshipType": "OWNER",
+				"identifier":       "urn:li:userGeneratedContent",
+			}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal upload registration: %v", err)
+	}
+	req, err := http.NewRequest("POST", "https://api.linkedin.com/v2/assets?action=registerUpload", bytes.NewBuffer(registerBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Restli-Protocol-Version", "2.0.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to register upload: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to register upload, status code: %d, response: %s", resp.StatusCode, body)
+	}
+	var registered struct {
+		Value struct {
+			Asset           string `json:"asset"`
+			UploadMechanism struct {
+				HttpRequest struct {
+					UploadUrl string `json:"uploadUrl"`
+				} `json:"com.linkedin.digitalmedia.uploadhttp.MediaUploadHttpRequest"`
+			} `json:"uploadMechanism"`
+		} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return "", fmt.Errorf("failed to parse upload registration: %v", err)
+	}
+	uploadUrl := registered.Value.UploadMechanism.HttpRequest.UploadUrl
+	if uploadUrl == "" || registered.Value.Asset == "" {
+		return "", fmt.Errorf("upload registration has no upload url or asset")
+	}
+
+	uploadReq, err := http.NewRequest("PUT", uploadUrl, bytes.NewReader(image))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	uploadReq.Header.Set("Authorization", "Bearer "+accessToken)
+	uploadReq.Header.Set("Content-Type", contentType)
+	uploadResp, err := httpClient.Do(uploadReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %v", err)
+	}
+	defer uploadResp.Body.Close()
+	if uploadResp.StatusCode != http.StatusOK && uploadResp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(uploadResp.Body)
+		return "", fmt.Errorf("failed to upload image, status code: %d, response: %s", uploadResp.StatusCode, body)
+	}
+	return registered.Value.Asset, nil
+}
+
 func getUserURN(accessToken string) (string, error) {
 	req, err := http.NewRequest("GET", "https://api.linkedin.com/v2/userinfo", nil)
 	if err != nil {
@@ -66,8 +194,7 @@ func getUserURN(accessToken string) (string, error) {
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %v", err)
 	}
@@ -88,3 +215,29 @@ func getUserURN(accessToken string) (string, error) {
 	}
 	return "urn:li:person:" + data.ID, nil
 }
+
+type linkedinPublisher struct{}
+
+func init() {
+	RegisterPublisher(linkedinPublisher{})
+}
+
+func (linkedinPublisher) Name() string        { return "linkedin" }
+func (linkedinPublisher) DisplayName() string { return "LinkedIn" }
+func (linkedinPublisher) MaxChars() int       { return 3000 }
+
+func (linkedinPublisher) Capabilities() Capabilities {
+	return Capabilities{Delete: true, Images: true}
+}
+
+func (linkedinPublisher) HasCredentials(user *models.User) bool {
+	return user.LinkedinVerified
+}
+
+func (linkedinPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	return linkedPostHandler(shareCopy.Posts["linkedin"], user.LinkedInOauthKey, shareCopy.Blog)
+}
+
+func (linkedinPublisher) Delete(user *models.User, postId string) error {
+	return linkedinDeleteHandler(postId, user.LinkedInOauthKey)
+}
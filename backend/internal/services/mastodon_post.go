@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/oauth2"
+	"social-scribe/backend/internal/models"
+	"social-scribe/backend/internal/repositories"
+)
+
+const mastodonScopes = "write:statuses"
+
+// NormalizeMastodonInstance turns what the user typed, "mastodon.social" or
+// "https://mastodon.social/@someone", into the host of the instance.
+func NormalizeMastodonInstance(raw string) (string, error) {
+	host, err := normalizeHost(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid Mastodon instance")
+	}
+	return host, nil
+}
+
+// normalizeHost takes the host out of a URL the user typed. Hosts we would never find a
+// public server on are refused, we make requests to whatever it is.
+func normalizeHost(raw string) (string, error) {
+	raw = strings.TrimSpace(strings.ToLower(raw))
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("invalid host")
+	}
+	host := parsed.Hostname()
+	if net.ParseIP(host) != nil || !strings.Contains(host, ".") || host == "localhost" || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return "", fmt.Errorf("invalid host")
+	}
+	return host, nil
+}
+
+// MastodonApp returns our OAuth app on the instance, registering it the first time a
+// user of the instance connects.
+func MastodonApp(instance, redirectURI, website string) (*models.MastodonApp, error) {
+	app, err := repositories.GetMastodonApp(instance)
+	if err != nil {
+		return nil, err
+	}
+	if app != nil && app.RedirectURI == redirectURI {
+		return app, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"client_name":   "Social Scribe",
+		"redirect_uris": redirectURI,
+		"scopes":        mastodonScopes,
+		"website":       website,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Post("https://"+instance+"/api/v1/apps", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to register app on %s: %v", instance, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to register app on %s, status code: %d, response: %s", instance, resp.StatusCode, respBody)
+	}
+
+	var registered struct {
+		ClientId     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return nil, fmt.Errorf("failed to parse app registration: %v", err)
+	}
+	app = &models.MastodonApp{
+		Instance:     instance,
+		ClientId:     registered.ClientId,
+		ClientSecret: registered.ClientSecret,
+		RedirectURI:  redirectURI,
+	}
+	if err := repositories.SaveMastodonApp(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// MastodonOAuthConfig is the OAuth config of our app on its instance.
+func MastodonOAuthConfig(app *models.MastodonApp) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     app.ClientId,
+		ClientSecret: app.ClientSecret,
+		RedirectURL:  app.RedirectURI,
+		Scopes:       []string{mastodonScopes},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   "https://" + app.Instance + "/oauth/authorize",
+			TokenURL:  "https://" + app.Instance + "/oauth/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+// ExchangeMastodonCode trades the code the instance redirected back with for the
+// user's access token.
+func ExchangeMastodonCode(app *models.MastodonApp, code string) (*oauth2.Token, error) {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+	return MastodonOAuthConfig(app).Exchange(ctx, code)
+}
+
+// postMastodonStatus posts the status and returns its id. The content warning counts
+// towards the character limit, so the status is trimmed to what is left of it.
+func postMastodonStatus(instance, accessToken, status string, settings models.MastodonSettings, idempotencyKey string) (string, error) {
+	limit := models.MastodonMaxChars - utf8.RuneCountInString(settings.SpoilerText)
+	if runes := []rune(status); len(runes) > limit {
+		status = string(runes[:limit-3]) + "..."
+	}
+	payload := map[string]interface{}{
+		"status":    status,
+		"sensitive": settings.Sensitive,
+	}
+	if settings.Visibility != "" {
+		payload["visibility"] = settings.Visibility
+	}
+	if settings.SpoilerText != "" {
+		payload["spoiler_text"] = settings.SpoilerText
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal status: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://"+instance+"/api/v1/statuses", bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	// a retry of the same share must not post the status twice
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send status: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to post status, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+
+	var created struct {
+		Id string `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Id, nil
+}
+
+func deleteMastodonStatus(instance, accessToken, statusId string) error {
+	req, err := http.NewRequest("DELETE", "https://"+instance+"/api/v1/statuses/"+url.PathEscape(statusId), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send delete request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete status, status code: %d, response: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+type mastodonPublisher struct{}
+
+func init() {
+	RegisterPublisher(mastodonPublisher{})
+}
+
+func (mastodonPublisher) Name() string        { return "mastodon" }
+func (mastodonPublisher) DisplayName() string { return "Mastodon" }
+func (mastodonPublisher) MaxChars() int       { return models.MastodonMaxChars }
+
+func (mastodonPublisher) Capabilities() Capabilities {
+	return Capabilities{Delete: true}
+}
+
+func (mastodonPublisher) HasCredentials(user *models.User) bool {
+	return user.MastodonVerified && user.MastodonInstance != "" && user.MastodonAccessToken != ""
+}
+
+func (mastodonPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	var settings models.MastodonSettings
+	if user.MastodonSettings != nil {
+		settings = *user.MastodonSettings
+	}
+	status := shareCopy.Posts["mastodon"]
+	key := hashText(fmt.Sprintf("%s:%d:%s", shareCopy.Blog.Id, shareCopy.Occurrence, status))
+	return postMastodonStatus(user.MastodonInstance, user.MastodonAccessToken, status, settings, key)
+}
+
+func (mastodonPublisher) Delete(user *models.User, postId string) error {
+	return deleteMastodonStatus(user.MastodonInstance, user.MastodonAccessToken, postId)
+}
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
+// httpClient is shared by the calls to Hashnode and the platforms we post to, they run
+// on the scheduler's workers so a stalled host has to fail instead of holding one.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
 func MakePostRequest(url string, body []byte, headers map[string]string) ([]byte, error) {
 	request, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	if err != nil {
@@ -17,8 +22,7 @@ func MakePostRequest(url string, body []byte, headers map[string]string) ([]byte
 		request.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
+	response, err := httpClient.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute HTTP request: %v", err)
 	}
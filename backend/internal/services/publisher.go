@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"social-scribe/backend/internal/models"
+)
+
+// Capabilities tells what a platform supports beyond posting text.
+type Capabilities struct {
+	// Delete is true when a published post can be taken down again
+	Delete bool
+	// Images is true when a post can carry the blog's cover image
+	Images bool
+	// Threads is true when a long post can go out as a chain of replies
+	Threads bool
+}
+
+// Publisher shares generated copy to one platform. Registering a publisher is all it
+// takes for schedules, share copy and the user's verification to pick a platform up.
+type Publisher interface {
+	// Name is the platform as it appears in schedules, share copy and share runs
+	Name() string
+	// DisplayName is the platform as shown to the user and the AI
+	DisplayName() string
+	Capabilities() Capabilities
+	// MaxChars is the longest post the platform takes, 0 when there is no limit
+	MaxChars() int
+	// HasCredentials tells whether the user connected an account to share with
+	HasCredentials(user *models.User) bool
+	// Publish posts the platform's copy and returns the id of the new post
+	Publish(user *models.User, shareCopy *models.ShareCopy) (string, error)
+	// Delete takes a published post down
+	Delete(user *models.User, postId string) error
+}
+
+// ThreadPublisher is a publisher that can post copy longer than MaxChars as a thread,
+// for users who turned that on.
+type ThreadPublisher interface {
+	Publisher
+	ThreadsEnabled(user *models.User) bool
+}
+
+var (
+	publishers     = map[string]Publisher{}
+	publisherOrder []string
+)
+
+// RegisterPublisher adds a platform, publishers register themselves in init.
+func RegisterPublisher(publisher Publisher) {
+	name := publisher.Name()
+	if _, ok := publishers[name]; !ok {
+		publisherOrder = append(publisherOrder, name)
+	}
+	publishers[name] = publisher
+	models.RegisterPlatform(name)
+}
+
+// GetPublisher returns the publisher of the platform.
+func GetPublisher(name string) (Publisher, bool) {
+	publisher, ok := publishers[name]
+	return publisher, ok
+}
+
+// Publishers returns every registered publisher in the order they registered.
+func Publishers() []Publisher {
+	list := make([]Publisher, 0, len(publisherOrder))
+	for _, name := range publisherOrder {
+		list = append(list, publishers[name])
+	}
+	return list
+}
+
+// ConnectedPublishers returns the publishers the user has credentials for.
+func ConnectedPublishers(user *models.User) []Publisher {
+	var connected []Publisher
+	for _, publisher := range Publishers() {
+		if publisher.HasCredentials(user) {
+			connected = append(connected, publisher)
+		}
+	}
+	return connected
+}
+
+// CanPublish tells whether the user is set up to share: the Hashnode blog to read
+// from and at least one platform to post to.
+func CanPublish(user *models.User) bool {
+	return user.HashnodeVerified && len(ConnectedPublishers(user)) > 0
+}
+
+// DeletePost takes down a post published to the platform.
+func DeletePost(user *models.User, platform string, postId string) error {
+	publisher, ok := GetPublisher(platform)
+	if !ok {
+		return fmt.Errorf("unknown platform %s", platform)
+	}
+	if !publisher.Capabilities().Delete {
+		return fmt.Errorf("posts on %s can't be deleted", publisher.DisplayName())
+	}
+	return publisher.Delete(user, postId)
+}
+
+// threaded tells whether the platform's copy of the user goes out as a thread.
+func threaded(user *models.User, publisher Publisher) bool {
+	threadPublisher, ok := publisher.(ThreadPublisher)
+	return ok && user != nil && publisher.Capabilities().Threads && threadPublisher.ThreadsEnabled(user)
+}
+
+// postTag marks the post of a platform in the AI response.
+func postTag(publisher Publisher) string {
+	return "[" + strings.ToUpper(publisher.Name()) + "]"
+}
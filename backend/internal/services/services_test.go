@@ -1,12 +1,19 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/dghubble/oauth1"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"social-scribe/backend/internal/models"
+	"social-scribe/backend/internal/repositories"
 )
 
 func TestInvokeAi_Success(t *testing.T) {
@@ -80,10 +87,643 @@ func TestLinkedPostHandler_Success(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	httpmock.RegisterResponder("GET", "https://api.linkedin.com/v2/userinfo", httpmock.NewStringResponder(200, `{"sub": "12345"}`))
-	httpmock.RegisterResponder("POST", "https://api.linkedin.com/v2/ugcPosts", httpmock.NewStringResponder(201, ""))
+	httpmock.RegisterResponder("POST", "https://api.linkedin.com/v2/ugcPosts",
+		httpmock.NewStringResponder(201, "").HeaderSet(map[string][]string{"X-RestLi-Id": {"urn:li:share:987"}}))
 
-	err := linkedPostHandler("Test LinkedIn post", "dummy_access_token")
+	postId, err := linkedPostHandler("Test LinkedIn post", "dummy_access_token", models.Blog{})
 	assert.NoError(t, err)
+	assert.Equal(t, "urn:li:share:987", postId)
+}
+
+func TestPostTweetHandler_ReturnsTweetId(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets",
+		httpmock.NewStringResponder(201, `{"data": {"id": "1445880548472328192", "text": "Test tweet"}}`))
+
+	postId, err := postTweetHandler("Test tweet", "blog1", oauth1.NewToken("token", "secret"), "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1445880548472328192", postId)
+}
+
+func TestSplitThread(t *testing.T) {
+	link := "https://me.hashnode.dev/go-tips"
+	text := "Go tips you wish you knew " + link + "\n---\n" + strings.Repeat("word ", 80) + "#golang"
+
+	tweets := splitThread(text, link, models.XLinkFirst, "blog1")
+	assert.Len(t, tweets, 3)
+	assert.Equal(t, "Go tips you wish you knew "+link+" 1/3", tweets[0])
+	assert.True(t, strings.HasSuffix(tweets[2], "#golang 3/3"))
+	for _, tweet := range tweets {
+		assert.LessOrEqual(t, tweetLength(tweet), tweetMaxChars)
+		assert.NotContains(t, tweet, "---")
+	}
+
+	tweets = splitThread(text, link, models.XLinkLast, "blog1")
+	assert.Len(t, tweets, 3)
+	assert.Equal(t, "Go tips you wish you knew 1/3", tweets[0])
+	assert.True(t, strings.HasSuffix(tweets[2], "#golang "+link+" 3/3"))
+
+	// copy that fits stays a single tweet without a number
+	assert.Equal(t, []string{"Short " + link}, splitThread("Short "+link, link, models.XLinkLast, "blog1"))
+}
+
+func TestSplitThread_FullThreadKeepsContent(t *testing.T) {
+	link := "https://me.hashnode.dev/go-tips"
+	// ten tweets the AI filled too far for the link to fit in any of them
+	var parts, words []string
+	for i := 0; i < models.XMaxThreadTweets; i++ {
+		var part []string
+		for j := 0; j < 45; j++ {
+			word := fmt.Sprintf("w%d.%d", i, j)
+			part = append(part, word)
+			words = append(words, word)
+		}
+		parts = append(parts, strings.Join(part, " "))
+	}
+	text := strings.Join(parts, "\n---\n")
+
+	for _, placement := range []string{models.XLinkFirst, models.XLinkLast} {
+		tweets := splitThread(text, link, placement, "blog1")
+		assert.Len(t, tweets, models.XMaxThreadTweets, placement)
+		joined := strings.Join(tweets, " ")
+		for _, word := range words {
+			assert.Contains(t, joined, word+" ", placement)
+		}
+		assert.Equal(t, 1, strings.Count(joined, link), placement)
+		for _, tweet := range tweets {
+			assert.LessOrEqual(t, tweetLength(tweet), tweetMaxChars, placement)
+		}
+	}
+	assert.Contains(t, splitThread(text, link, models.XLinkLast, "blog1")[models.XMaxThreadTweets-1], link)
+}
+
+func TestTwitterPublisher_PostsThread(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var replies []string
+	next, failOn := 0, 0
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets", func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Reply struct {
+				InReplyTo string `json:"in_reply_to_tweet_id"`
+			} `json:"reply"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		replies = append(replies, body.Reply.InReplyTo)
+		next++
+		if next == failOn {
+			return httpmock.NewStringResponse(429, `{"title": "Too Many Requests"}`), nil
+		}
+		return httpmock.NewStringResponse(201, fmt.Sprintf(`{"data": {"id": "%d"}}`, next)), nil
+	})
+	httpmock.RegisterResponder("DELETE", `=~^https://api\.twitter\.com/2/tweets/\d+$`,
+		httpmock.NewStringResponder(200, `{"data": {"deleted": true}}`))
+
+	user := &models.User{XVerified: true, XThread: &models.XThreadSettings{Enabled: true}}
+	shareCopy := &models.ShareCopy{
+		Blog:  models.Blog{Id: "blog1", Url: "https://me.hashnode.dev/go-tips"},
+		Posts: map[string]string{"twitter": "one\n---\ntwo"},
+	}
+	postId, err := twitterPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, "1,2", postId)
+	assert.Equal(t, []string{"", "1"}, replies)
+
+	// a thread that breaks halfway is taken down
+	failOn = 4
+	shareCopy.Posts["twitter"] = "three\n---\nfour"
+	_, err = twitterPublisher{}.Publish(user, shareCopy)
+	assert.ErrorContains(t, err, "tweet 2 of 2")
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["DELETE https://api.twitter.com/2/tweets/3"])
+
+	assert.NoError(t, twitterPublisher{}.Delete(user, "1,2"))
+}
+
+func TestTwitterPublisher_ThreadKeepsLongLink(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent []string
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets", func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		sent = append(sent, body.Text)
+		return httpmock.NewStringResponse(201, fmt.Sprintf(`{"data": {"id": "%d"}}`, len(sent))), nil
+	})
+
+	link := "https://janedoe.hashnode.dev/building-resilient-background-job-schedulers-in-go-with-redis-and-mongodb"
+	user := &models.User{XVerified: true, XThread: &models.XThreadSettings{Enabled: true, LinkPlacement: models.XLinkFirst}}
+	shareCopy := &models.ShareCopy{
+		Blog:  models.Blog{Id: "blog1", Url: link},
+		Posts: map[string]string{"twitter": strings.Repeat("scheduling ", 20) + link + "\n---\nsecond tweet"},
+	}
+	_, err := twitterPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	if assert.Len(t, sent, 2) {
+		assert.Greater(t, len([]rune(sent[0])), tweetMaxChars)
+		assert.True(t, strings.HasSuffix(sent[0], link+" 1/2"), sent[0])
+		assert.True(t, strings.HasSuffix(sent[1], " 2/2"), sent[1])
+	}
+}
+
+func TestTwitterPublisher_AttachesCoverImage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://cdn.hashnode.com/cover.png",
+		httpmock.NewBytesResponder(200, []byte("\x89PNG\r\n\x1a\n")))
+	httpmock.RegisterResponder("POST", "https://upload.twitter.com/1.1/media/upload.json",
+		httpmock.NewStringResponder(200, `{"media_id": 710511363345354753, "media_id_string": "710511363345354753"}`))
+	var alt map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://upload.twitter.com/1.1/media/metadata/create.json", func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&alt)
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets", func(req *http.Request) (*http.Response, error) {
+		sent = nil
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(201, `{"data": {"id": "42"}}`), nil
+	})
+
+	user := &models.User{XVerified: true}
+	shareCopy := &models.ShareCopy{
+		Blog:  models.Blog{Id: "blog1", Title: "Go tips", CoverImage: models.Image{URL: "https://cdn.hashnode.com/cover.png"}},
+		Posts: map[string]string{"twitter": "tweet"},
+	}
+	_, err := twitterPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"media_ids": []interface{}{"710511363345354753"}}, sent["media"])
+	assert.Equal(t, "Go tips", alt["alt_text"].(map[string]interface{})["text"])
+
+	// the tweet still goes out when the image doesn't
+	httpmock.RegisterResponder("GET", "https://cdn.hashnode.com/cover.png", httpmock.NewStringResponder(404, ""))
+	_, err = twitterPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.NotContains(t, sent, "media")
+}
+
+func TestLinkedPostHandler_AttachesCoverImage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "https://api.linkedin.com/v2/userinfo", httpmock.NewStringResponder(200, `{"sub": "12345"}`))
+	httpmock.RegisterResponder("GET", "https://cdn.hashnode.com/cover.png",
+		httpmock.NewBytesResponder(200, []byte("\x89PNG\r\n\x1a\n")))
+	httpmock.RegisterResponder("POST", "https://api.linkedin.com/v2/assets?action=registerUpload",
+		httpmock.NewStringResponder(200, `{"value": {"asset": "urn:li:digitalmediaAsset:C5522AQ", "uploadMechanism": {"com.linkedin.digitalmedia.uploadhttp.MediaUploadHttpRequest": {"uploadUrl": "https://api.linkedin.com/mediaUpload/C5522AQ"}}}}`))
+	httpmock.RegisterResponder("PUT", "https://api.linkedin.com/mediaUpload/C5522AQ", httpmock.NewStringResponder(201, ""))
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://api.linkedin.com/v2/ugcPosts", func(req *http.Request) (*http.Response, error) {
+		sent = nil
+		json.NewDecoder(req.Body).Decode(&sent)
+		resp := httpmock.NewStringResponse(201, "")
+		resp.Header.Set("X-RestLi-Id", "urn:li:share:1")
+		return resp, nil
+	})
+
+	blog := models.Blog{Id: "blog1", Title: "Go tips", CoverImage: models.Image{URL: "https://cdn.hashnode.com/cover.png"}}
+	_, err := linkedPostHandler("post", "token", blog)
+	assert.NoError(t, err)
+	content := sent["specificContent"].(map[string]interface{})["com.linkedin.ugc.ShareContent"].(map[string]interface{})
+	assert.Equal(t, "IMAGE", content["shareMediaCategory"])
+	media := content["media"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "urn:li:digitalmediaAsset:C5522AQ", media["media"])
+	assert.Equal(t, "Go tips", media["description"].(map[string]interface{})["text"])
+
+	// a failed upload falls back to a text-only post
+	httpmock.RegisterResponder("POST", "https://api.linkedin.com/v2/assets?action=registerUpload", httpmock.NewStringResponder(403, ""))
+	_, err = linkedPostHandler("post", "token", blog)
+	assert.NoError(t, err)
+	content = sent["specificContent"].(map[string]interface{})["com.linkedin.ugc.ShareContent"].(map[string]interface{})
+	assert.Equal(t, "NONE", content["shareMediaCategory"])
+	assert.NotContains(t, content, "media")
+}
+
+func TestPublishShareCopy_RecordsOutcomes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets",
+		httpmock.NewStringResponder(201, `{"data": {"id": "42"}}`))
+	httpmock.RegisterResponder("GET", "https://api.linkedin.com/v2/userinfo", httpmock.NewStringResponder(401, ""))
+
+	user := &models.User{Verified: true, XVerified: true, LinkedinVerified: true}
+	shareCopy := &models.ShareCopy{Posts: map[string]string{"twitter": "tweet", "linkedin": "post"}}
+	outcomes, err := PublishShareCopy(user, shareCopy, []string{"twitter", "linkedin"})
+
+	var publishErr *PublishError
+	assert.ErrorAs(t, err, &publishErr)
+	assert.Equal(t, []string{"twitter"}, publishErr.Published)
+	assert.Len(t, outcomes, 2)
+	assert.Equal(t, models.PlatformOutcome{Platform: "twitter", Status: models.TaskStatusShared, PostId: "42"}, outcomes[0])
+	assert.Equal(t, models.TaskStatusFailed, outcomes[1].Status)
+	assert.NotEmpty(t, outcomes[1].Error)
+}
+
+func TestPublishShareCopy_NeedsCredentials(t *testing.T) {
+	user := &models.User{Verified: true, XVerified: true}
+	shareCopy := &models.ShareCopy{Posts: map[string]string{"twitter": "tweet", "linkedin": "post"}}
+
+	_, err := PublishShareCopy(user, shareCopy, []string{"twitter", "linkedin"})
+	assert.EqualError(t, err, "LinkedIn is not connected")
+	_, err = PublishShareCopy(user, shareCopy, []string{"myspace"})
+	assert.EqualError(t, err, "invalid platform specified")
+}
+
+func TestSplitPosts(t *testing.T) {
+	twitter, _ := GetPublisher("twitter")
+	linkedin, _ := GetPublisher("linkedin")
+	aiResponse := "[LINKEDIN]\nA longer post\n\n[TWITTER]\nA tweet #go\n"
+
+	posts := splitPosts(aiResponse, []Publisher{twitter, linkedin})
+	assert.Equal(t, map[string]string{"twitter": "A tweet #go", "linkedin": "A longer post"}, posts)
+	assert.Contains(t, sharePrompt(nil, []Publisher{twitter, linkedin}, "title", "url", "", "", "", ""), "**280 characters or less**")
+}
+
+func TestCanPublish(t *testing.T) {
+	assert.False(t, CanPublish(&models.User{HashnodeVerified: true}))
+	assert.False(t, CanPublish(&models.User{LinkedinVerified: true}))
+	assert.True(t, CanPublish(&models.User{HashnodeVerified: true, LinkedinVerified: true}))
+}
+
+func TestDeletePost(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("DELETE", "https://api.twitter.com/2/tweets/42",
+		httpmock.NewStringResponder(200, `{"data": {"deleted": true}}`))
+	httpmock.RegisterResponder("DELETE", "https://api.linkedin.com/v2/ugcPosts/urn:li:share:987",
+		httpmock.NewStringResponder(204, ""))
+
+	user := &models.User{XOAuthToken: "token", XOAuthSecret: "secret", LinkedInOauthKey: "key"}
+	assert.NoError(t, DeletePost(user, "twitter", "42"))
+	assert.NoError(t, DeletePost(user, "linkedin", "urn:li:share:987"))
+	assert.Error(t, DeletePost(user, "myspace", "1"))
+}
+
+func TestNormalizeMastodonInstance(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"mastodon.social":                  "mastodon.social",
+		" https://Fosstodon.org/@someone ": "fosstodon.org",
+		"hachyderm.io:443":                 "hachyderm.io",
+	} {
+		instance, err := NormalizeMastodonInstance(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, instance)
+	}
+	for _, raw := range []string{"", "localhost", "http://127.0.0.1", "metadata.google.internal"} {
+		_, err := NormalizeMastodonInstance(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestMastodonApp_RegistersOnce(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://mastodon.social/api/v1/apps",
+		httpmock.NewStringResponder(200, `{"client_id": "id", "client_secret": "secret"}`))
+	var saved *models.MastodonApp
+	repositories.GetMastodonApp = func(instance string) (*models.MastodonApp, error) {
+		return saved, nil
+	}
+	repositories.SaveMastodonApp = func(app *models.MastodonApp) error {
+		saved = app
+		return nil
+	}
+
+	app, err := MastodonApp("mastodon.social", "https://example.com/callback", "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "id", app.ClientId)
+	_, err = MastodonApp("mastodon.social", "https://example.com/callback", "https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.Equal(t, "https://mastodon.social/oauth/authorize", MastodonOAuthConfig(app).Endpoint.AuthURL)
+}
+
+func TestPostMastodonStatus_ContentWarning(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://mastodon.social/api/v1/statuses", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		assert.Equal(t, "key", req.Header.Get("Idempotency-Key"))
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(200, `{"id": "1099"}`), nil
+	})
+
+	settings := models.MastodonSettings{Visibility: models.MastodonUnlisted, SpoilerText: "blog spam", Sensitive: true}
+	postId, err := postMastodonStatus("mastodon.social", "token", strings.Repeat("a", 600), settings, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "1099", postId)
+	assert.Equal(t, "unlisted", sent["visibility"])
+	assert.Equal(t, "blog spam", sent["spoiler_text"])
+	assert.Equal(t, true, sent["sensitive"])
+	// the warning counts towards the 500 characters
+	assert.Len(t, []rune(sent["status"].(string)), 500-len("blog spam"))
+}
+
+func TestGraphemeCount(t *testing.T) {
+	assert.Equal(t, 5, graphemeCount("hello"))
+	assert.Equal(t, 4, graphemeCount("café"))
+	// combining accent, a family joined with ZWJ, a skin tone and a flag
+	assert.Equal(t, 1, graphemeCount("e\u0301"))
+	assert.Equal(t, 1, graphemeCount("👩\u200d👩\u200d👧"))
+	assert.Equal(t, 1, graphemeCount("👍🏽"))
+	assert.Equal(t, 2, graphemeCount("🇩🇪🇫🇷"))
+
+	trimmed := trimGraphemes(strings.Repeat("word ", 100), BlueskyMaxGraphemes)
+	assert.LessOrEqual(t, graphemeCount(trimmed), BlueskyMaxGraphemes)
+	assert.True(t, strings.HasSuffix(trimmed, "word…"))
+}
+
+func TestBlueskyFacets(t *testing.T) {
+	text := "Über Go: https://blog.example.com/go-tips. #golang #100DaysOfCode"
+	facets := blueskyFacets(text)
+	assert.Len(t, facets, 3)
+
+	link := facets[0]
+	index := link["index"].(map[string]int)
+	assert.Equal(t, "https://blog.example.com/go-tips", text[index["byteStart"]:index["byteEnd"]])
+	assert.Equal(t, "https://blog.example.com/go-tips", link["features"].([]map[string]interface{})[0]["uri"])
+
+	tag := facets[1]
+	index = tag["index"].(map[string]int)
+	assert.Equal(t, "#golang", text[index["byteStart"]:index["byteEnd"]])
+	assert.Equal(t, "golang", tag["features"].([]map[string]interface{})[0]["tag"])
+}
+
+func TestBlueskyPublisher_Publish(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://bsky.social/xrpc/com.atproto.server.createSession",
+		httpmock.NewStringResponder(200, `{"accessJwt": "jwt", "did": "did:plc:abc", "handle": "me.bsky.social"}`))
+	httpmock.RegisterResponder("GET", "https://cdn.example.com/cover.png",
+		httpmock.NewBytesResponder(200, []byte("\x89PNG\r\n\x1a\n")))
+	httpmock.RegisterResponder("POST", "https://bsky.social/xrpc/com.atproto.repo.uploadBlob",
+		httpmock.NewStringResponder(200, `{"blob": {"$type": "blob", "ref": {"$link": "bafk"}, "mimeType": "image/png", "size": 8}}`))
+	var sent struct {
+		Repo   string                 `json:"repo"`
+		Record map[string]interface{} `json:"record"`
+	}
+	httpmock.RegisterResponder("POST", "https://bsky.social/xrpc/com.atproto.repo.createRecord", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer jwt", req.Header.Get("Authorization"))
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(200, `{"uri": "at://did:plc:abc/app.bsky.feed.post/3k2a", "cid": "bafy"}`), nil
+	})
+
+	user := &models.User{BlueskyHandle: "me.bsky.social", BlueskyAppPassword: "app-pass", BlueskyVerified: true}
+	shareCopy := &models.ShareCopy{
+		Blog: models.Blog{
+			Id:         "blog1",
+			Title:      "Go tips",
+			Url:        "https://blog.example.com/go-tips",
+			CoverImage: models.Image{URL: "https://cdn.example.com/cover.png"},
+			Author:     models.Author{Name: "Ada"},
+		},
+		Posts: map[string]string{"bluesky": strings.Repeat("go ", 200) + "https://blog.example.com/go-tips"},
+	}
+	postId, err := blueskyPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, "at://did:plc:abc/app.bsky.feed.post/3k2a", postId)
+	assert.Equal(t, "did:plc:abc", sent.Repo)
+	assert.LessOrEqual(t, graphemeCount(sent.Record["text"].(string)), BlueskyMaxGraphemes)
+
+	// the link was trimmed off, the card still carries it
+	external := sent.Record["embed"].(map[string]interface{})["external"].(map[string]interface{})
+	assert.Equal(t, "https://blog.example.com/go-tips", external["uri"])
+	assert.Equal(t, "Go tips", external["title"])
+	assert.NotNil(t, external["thumb"])
+}
+
+func TestWebhookKind(t *testing.T) {
+	kind, err := WebhookKind("https://discord.com/api/webhooks/123/token")
+	assert.NoError(t, err)
+	assert.Equal(t, WebhookDiscord, kind)
+	kind, err = WebhookKind("https://hooks.slack.com/services/T0/B0/token")
+	assert.NoError(t, err)
+	assert.Equal(t, WebhookSlack, kind)
+
+	for _, raw := range []string{"", "http://discord.com/api/webhooks/123/token", "https://example.com/api/webhooks/1", "https://hooks.slack.com.evil.io/services/x"} {
+		_, err := WebhookKind(raw)
+		assert.Error(t, err, raw)
+	}
+	assert.Equal(t, "https://hooks.slack.com/…", MaskWebhookURL("https://hooks.slack.com/services/T0/B0/token"))
+}
+
+func TestWebhookPublisher_Discord(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://discord.com/api/webhooks/123/token", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "true", req.URL.Query().Get("wait"))
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(200, `{"id": "msg1"}`), nil
+	})
+
+	user := &models.User{WebHookUrl: "https://discord.com/api/webhooks/123/token"}
+	shareCopy := &models.ShareCopy{
+		Blog: models.Blog{
+			Title:             "Go tips",
+			Url:               "https://blog.example.com/go-tips",
+			CoverImage:        models.Image{URL: "https://cdn.example.com/cover.png"},
+			Author:            models.Author{Name: "Ada"},
+			ReadTimeInMinutes: 4,
+		},
+		Posts: map[string]string{"webhook": "New post!"},
+	}
+	postId, err := webhookPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, "msg1", postId)
+	assert.Equal(t, "New post!", sent["content"])
+	embed := sent["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Go tips", embed["title"])
+	assert.Equal(t, "https://blog.example.com/go-tips", embed["url"])
+	assert.Equal(t, "Ada", embed["author"].(map[string]interface{})["name"])
+	assert.Equal(t, "4 min read", embed["footer"].(map[string]interface{})["text"])
+	assert.Equal(t, "https://cdn.example.com/cover.png", embed["image"].(map[string]interface{})["url"])
+}
+
+func TestWebhookPublisher_Slack(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://hooks.slack.com/services/T0/B0/token", func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(200, "ok"), nil
+	})
+
+	user := &models.User{WebHookUrl: "https://hooks.slack.com/services/T0/B0/token"}
+	shareCopy := &models.ShareCopy{
+		Blog:  models.Blog{Title: "Go <tips>", Url: "https://blog.example.com/go-tips", Author: models.Author{Name: "Ada"}, ReadTimeInMinutes: 4},
+		Posts: map[string]string{"webhook": "New post!"},
+	}
+	_, err := webhookPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, "New post!", sent["text"])
+	blocks := sent["blocks"].([]interface{})
+	assert.Len(t, blocks, 3)
+	title := blocks[1].(map[string]interface{})["text"].(map[string]interface{})["text"]
+	assert.Equal(t, "*<https://blog.example.com/go-tips|Go &lt;tips&gt;>*", title)
+	byline := blocks[2].(map[string]interface{})["elements"].([]interface{})[0].(map[string]interface{})["text"]
+	assert.Equal(t, "By Ada · 4 min read", byline)
+}
+
+func TestValidateTelegramCredentials(t *testing.T) {
+	assert.NoError(t, ValidateTelegramCredentials("123456:ABC-def_ghi", "@my_channel"))
+	assert.NoError(t, ValidateTelegramCredentials("123456:ABC-def_ghi", "-1001234567890"))
+	assert.Error(t, ValidateTelegramCredentials("123456:ABC/../x", "@my_channel"))
+	assert.Error(t, ValidateTelegramCredentials("123456:ABC", "my channel"))
+}
+
+func TestTelegramPublisher_Publish(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent map[string]interface{}
+	httpmock.RegisterResponder("POST", "https://api.telegram.org/bot123:abc/sendPhoto", func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(200, `{"ok": true, "result": {"message_id": 42}}`), nil
+	})
+	httpmock.RegisterResponder("POST", "https://api.telegram.org/bot123:abc/deleteMessage",
+		httpmock.NewStringResponder(400, `{"ok": false, "description": "Bad Request: message can't be deleted"}`))
+
+	user := &models.User{TelegramBotToken: "123:abc", TelegramChatId: "@blog", TelegramVerified: true}
+	shareCopy := &models.ShareCopy{
+		Blog:  models.Blog{Title: "Go <tips> & tricks", CoverImage: models.Image{URL: "https://cdn.example.com/cover.png"}},
+		Posts: map[string]string{"telegram": strings.Repeat("a", 2000)},
+	}
+	postId, err := telegramPublisher{}.Publish(user, shareCopy)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", postId)
+	assert.Equal(t, "@blog", sent["chat_id"])
+	assert.Equal(t, "https://cdn.example.com/cover.png", sent["photo"])
+	assert.Equal(t, "HTML", sent["parse_mode"])
+	caption := sent["caption"].(string)
+	header := "<b>Go &lt;tips&gt; &amp; tricks</b>\n\n"
+	assert.True(t, strings.HasPrefix(caption, header))
+	// the limit counts the caption without its markup
+	visible := len([]rune("Go <tips> & tricks\n\n")) + len([]rune(strings.TrimPrefix(caption, header)))
+	assert.Equal(t, telegramMaxCaption, visible)
+
+	err = telegramPublisher{}.Delete(user, postId)
+	assert.ErrorContains(t, err, "message can't be deleted")
+}
+
+func TestDevtoMarkdown(t *testing.T) {
+	markdown := "Intro\n\n![diagram](https://cdn.hashnode.com/a.png align=\"center\")\n\n![](https://cdn.hashnode.com/b.png align=\"left\" fullwidth=\"true\")\n[link](https://example.com)"
+	assert.Equal(t, "Intro\n\n![diagram](https://cdn.hashnode.com/a.png)\n\n![](https://cdn.hashnode.com/b.png)\n[link](https://example.com)", devtoMarkdown(markdown))
+}
+
+func TestCrossPostToDevto_CreatesThenUpdates(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	markdown := "# Go tips\n\nFirst version"
+	httpmock.RegisterResponder("POST", "https://gql.hashnode.com", func(req *http.Request) (*http.Response, error) {
+		var query models.GraphQLQuery
+		json.NewDecoder(req.Body).Decode(&query)
+		assert.Contains(t, query.Query, "markdown")
+		post := map[string]interface{}{
+			"id":         "blog1",
+			"title":      "Go tips",
+			"url":        "https://me.hashnode.dev/go-tips",
+			"brief":      "Tips for Go",
+			"coverImage": map[string]string{"url": "https://cdn.hashnode.com/cover.png"},
+			"content":    map[string]string{"markdown": markdown},
+			"tags":       []map[string]string{{"slug": "go"}, {"slug": "web-development"}, {"slug": "go"}},
+		}
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"data": map[string]interface{}{"post": post}})
+	})
+	var sent struct {
+		Article map[string]interface{} `json:"article"`
+	}
+	httpmock.RegisterResponder("POST", "https://dev.to/api/articles", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "devto-key", req.Header.Get("api-key"))
+		json.NewDecoder(req.Body).Decode(&sent)
+		return httpmock.NewStringResponse(201, `{"id": 77, "url": "https://dev.to/me/go-tips-1"}`), nil
+	})
+	httpmock.RegisterResponder("PUT", "https://dev.to/api/articles/77",
+		httpmock.NewStringResponder(200, `{"id": 77, "url": "https://dev.to/me/go-tips-1"}`))
+
+	var saved *models.CrossPost
+	repositories.GetCrossPost = func(userId, blogId, platform string) (*models.CrossPost, error) {
+		return saved, nil
+	}
+	repositories.SaveCrossPost = func(crossPost *models.CrossPost) error {
+		saved = crossPost
+		return nil
+	}
+
+	user := &models.User{DevtoApiKey: "devto-key", DevtoVerified: true}
+	crossPost, err := CrossPostToDevto(user, "blog1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "77", crossPost.RemoteId)
+	assert.Equal(t, "https://dev.to/me/go-tips-1", crossPost.RemoteUrl)
+	assert.Equal(t, "https://me.hashnode.dev/go-tips", sent.Article["canonical_url"])
+	assert.Equal(t, markdown, sent.Article["body_markdown"])
+	assert.Equal(t, []interface{}{"go", "webdevelopment"}, sent.Article["tags"])
+	assert.Equal(t, "https://cdn.hashnode.com/cover.png", sent.Article["main_image"])
+
+	// nothing changed, nothing is sent
+	_, err = CrossPostToDevto(user, "blog1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["PUT https://dev.to/api/articles/77"])
+
+	// an edit of the blog updates the same article
+	markdown = "# Go tips\n\nSecond version"
+	_, err = CrossPostToDevto(user, "blog1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["PUT https://dev.to/api/articles/77"])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["POST https://dev.to/api/articles"])
+}
+
+func TestProcessSharedBlog_RecordsPublishedOnFailure(t *testing.T) {
+	os.Setenv("GEMINI_API_KEY", "dummy_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "https://gql.hashnode.com", httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+		"data": map[string]interface{}{"post": map[string]interface{}{
+			"id":    "blog1",
+			"title": "Go tips",
+			"url":   "https://me.hashnode.dev/go-tips",
+		}},
+	}))
+	httpmock.RegisterResponder("POST", `=~^https://generativelanguage\.googleapis\.com/`,
+		httpmock.NewStringResponder(200, `{"candidates": [{"content": {"parts": [{"text": "[TWITTER]\nGo tips https://me.hashnode.dev/go-tips\n[WEBHOOK]\nGo tips"}]}}]}`))
+	httpmock.RegisterResponder("POST", "https://api.twitter.com/2/tweets",
+		httpmock.NewStringResponder(201, `{"data": {"id": "42"}}`))
+	httpmock.RegisterResponder("POST", `=~^https://discord\.com/api/webhooks/`,
+		httpmock.NewStringResponder(500, `{"message": "boom"}`))
+
+	repositories.InsertShareRun = func(run *models.ShareRun) error {
+		return nil
+	}
+	var saved *models.User
+	repositories.UpdateUser = func(userId string, user *models.User) error {
+		saved = user
+		return nil
+	}
+
+	user := &models.User{Id: primitive.NewObjectID(), Verified: true, XVerified: true, WebHookUrl: "https://discord.com/api/webhooks/1/token"}
+	err := ProcessSharedBlog(user, "blog1", []string{"twitter", "webhook"})
+	var publishErr *PublishError
+	assert.ErrorAs(t, err, &publishErr)
+	assert.Equal(t, []string{"twitter"}, publishErr.Published)
+	if assert.NotNil(t, saved) && assert.Len(t, saved.SharedBlogs, 1) {
+		assert.Equal(t, []string{"twitter"}, saved.SharedBlogs[0].Platforms)
+	}
 }
 
 func TestGenerateOTP(t *testing.T) {
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"social-scribe/backend/internal/models"
+	"social-scribe/backend/internal/repositories"
+)
+
+// StartShareRun starts the record of a share execution, FinishShareRun fills in the
+// outcome and stores it.
+func StartShareRun(userId, blogId, trigger string) *models.ShareRun {
+	return &models.ShareRun{
+		UserID:    userId,
+		BlogId:    blogId,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+	}
+}
+
+// FinishShareRun stores the run with its outcome. Platforms without an outcome, because
+// the run failed before posting, are recorded as not attempted. A run that can't be
+// stored is only logged, the share itself already happened.
+func FinishShareRun(run *models.ShareRun, shareCopy *models.ShareCopy, platforms []string, outcomes []models.PlatformOutcome, err error) {
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	if shareCopy != nil {
+		run.BlogTitle = shareCopy.Blog.Title
+		run.PromptHash = shareCopy.PromptHash
+		run.ResponseHash = shareCopy.ResponseHash
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.Platforms = outcomes
+	if len(run.Platforms) == 0 {
+		for _, platform := range platforms {
+			run.Platforms = append(run.Platforms, models.PlatformOutcome{Platform: platform, Status: models.PlatformNotAttempted})
+		}
+	}
+
+	if err := repositories.InsertShareRun(run); err != nil {
+		log.Printf("[ERROR] Failed to record the share run of blog id %s for user id %s: %v", run.BlogId, run.UserID, err)
+	}
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
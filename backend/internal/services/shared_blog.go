@@ -2,33 +2,129 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/dghubble/oauth1"
 	"social-scribe/backend/internal/models"
 	"social-scribe/backend/internal/repositories"
 )
 
-func ProcessSharedBlog(user *models.User, blogId string, platforms []string) error {
+// PublishError is returned by ProcessSharedBlog when posting to one of the platforms
+// fails, Published holds the platforms that already went out before the failure so
+// a retry doesn't post them twice.
+type PublishError struct {
+	Platform  string
+	Published []string
+	Err       error
+}
+
+func (e *PublishError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
+func ProcessSharedBlog(user *models.User, blogId string, platforms []string) (err error) {
 	userId := user.Id.Hex()
+	run := StartShareRun(userId, blogId, models.ShareTriggerImmediate)
+	var shareCopy *models.ShareCopy
+	var outcomes []models.PlatformOutcome
+	defer func() {
+		FinishShareRun(run, shareCopy, platforms, outcomes, err)
+	}()
+
+	if err := checkCanShare(user, platforms); err != nil {
+		return err
+	}
 
+	shareCopy, err = GenerateShareCopy(user, blogId)
+	if err != nil {
+		return err
+	}
+
+	outcomes, err = PublishShareCopy(user, shareCopy, platforms)
+	if err != nil {
+		// platforms that already went out before the failure shouldn't be posted again
+		var publishErr *PublishError
+		if errors.As(err, &publishErr) && len(publishErr.Published) > 0 {
+			RecordSharedBlog(user, shareCopy.Blog, publishErr.Published)
+			if updateErr := repositories.UpdateUser(userId, user); updateErr != nil {
+				log.Printf("[ERROR] Failed to update user with shared blog: %v", updateErr)
+			}
+		}
+		return err
+	}
+
+	RecordSharedBlog(user, shareCopy.Blog, platforms)
+	if err := repositories.UpdateUser(userId, user); err != nil {
+		return fmt.Errorf("failed to update user with shared blog: %v", err)
+	}
+	return nil
+}
+
+func checkCanShare(user *models.User, platforms []string) error {
 	if !user.Verified {
 		return fmt.Errorf("user is not verified")
 	}
-	validPlatforms := map[string]bool{
-		"twitter":  true,
-		"linkedin": true,
-	}
 	if len(platforms) == 0 {
 		return fmt.Errorf("at least one platform must be specified")
 	}
 	for _, platform := range platforms {
-		if !validPlatforms[platform] {
+		publisher, ok := GetPublisher(platform)
+		if !ok {
 			return fmt.Errorf("invalid platform specified")
 		}
+		if !publisher.HasCredentials(user) {
+			return fmt.Errorf("%s is not connected", publisher.DisplayName())
+		}
+	}
+	return nil
+}
+
+// hashnodePost is a post as the Hashnode post query returns it.
+type hashnodePost struct {
+	Id         string `json:"id"`
+	Title      string `json:"title"`
+	Url        string `json:"url"`
+	CoverImage struct {
+		Url string `json:"url"`
+	} `json:"coverImage"`
+	Author struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	ReadTimeInMinutes int    `json:"readTimeInMinutes"`
+	SubTitle          string `json:"subtitle"`
+	Brief             string `json:"brief"`
+	Content           struct {
+		Text     string `json:"text"`
+		Markdown string `json:"markdown"`
+	} `json:"content"`
+	Tags []struct {
+		Slug string `json:"slug"`
+	} `json:"tags"`
+}
+
+// blog is the part of the post share copy keeps.
+func (p *hashnodePost) blog() models.Blog {
+	return models.Blog{
+		Id:                p.Id,
+		Title:             p.Title,
+		Url:               p.Url,
+		CoverImage:        models.Image{URL: p.CoverImage.Url},
+		Author:            models.Author{Name: p.Author.Name},
+		ReadTimeInMinutes: p.ReadTimeInMinutes,
 	}
+}
+
+// fetchHashnodePost runs the Hashnode post query, the content comes as plain text for
+// the AI and as markdown for cross posting.
+func fetchHashnodePost(blogId string) (*hashnodePost, error) {
 	query := models.GraphQLQuery{
 		Query: `query Post($id: ID!) {
             post(id: $id) {
@@ -46,6 +142,10 @@ func ProcessSharedBlog(user *models.User, blogId string, platforms []string) err
                 brief
                 content {
                     text
+                    markdown
+                }
+                tags {
+                    slug
                 }
             }
         }`,
@@ -55,138 +155,198 @@ func ProcessSharedBlog(user *models.User, blogId string, platforms []string) err
 	}
 	queryBytes, err := json.Marshal(query)
 	if err != nil {
-		return fmt.Errorf("failed to marshal query: %v", err)
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
 	}
 	endpoint := "https://gql.hashnode.com"
 	headers := map[string]string{"Content-Type": "application/json"}
 	gqlResponse, err := MakePostRequest(endpoint, queryBytes, headers)
 	if err != nil {
-		return fmt.Errorf("failed to make request: %v", err)
+		return nil, fmt.Errorf("failed to make request: %v", err)
 	}
 	var response struct {
 		Data struct {
-			Post struct {
-				Id         string `json:"id"`
-				Title      string `json:"title"`
-				Url        string `json:"url"`
-				CoverImage struct {
-					Url string `json:"url"`
-				} `json:"coverImage"`
-				Author struct {
-					Name string `json:"name"`
-				} `json:"author"`
-				ReadTimeInMinutes int    `json:"readTimeInMinutes"`
-				SubTitle          string `json:"subtitle"`
-				Brief             string `json:"brief"`
-				Content           struct {
-					Text string `json:"text"`
-				} `json:"content"`
-			} `json:"post"`
+			Post *hashnodePost `json:"post"`
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(gqlResponse, &response); err != nil {
-		return fmt.Errorf("failed to unmarshal response: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	if response.Data.Post == nil {
+		return nil, fmt.Errorf("blog %s not found on Hashnode", blogId)
+	}
+	return response.Data.Post, nil
+}
+
+// GenerateShareCopy fetches the blog from Hashnode and asks the AI for the posts of
+// every platform at once, so targets of the same schedule can share one copy.
+func GenerateShareCopy(user *models.User, blogId string) (*models.ShareCopy, error) {
+	post, err := fetchHashnodePost(blogId)
+	if err != nil {
+		return nil, err
 	}
 	const maxContentLength = 150
-	content := response.Data.Post.Content.Text
+	content := post.Content.Text
 	if len(content) > maxContentLength {
 		content = content[:maxContentLength] + "..."
 	}
-	// so the idea is to tell the ai to generate both posts in a single request
-	prompt := fmt.Sprintf(
-		"Generate two separate social media posts for this blog, one for Twitter (X) and one for LinkedIn.\n\n"+
+	// recurring shares go through here again, so ask for a different angle instead of
+	// repeating the same announcement every time
+	var reshareNote string
+	for i := range user.SharedBlogs {
+		if user.SharedBlogs[i].Id == blogId {
+			reshareNote = "- This blog has already been shared before, write **fresh copy** with a different hook than a plain announcement.\n"
+			break
+		}
+	}
+	// so the idea is to tell the ai to generate the posts of every platform in a single request
+	targets := ConnectedPublishers(user)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no platform is connected")
+	}
+	prompt := sharePrompt(user, targets, post.Title, post.Url, post.SubTitle, post.Brief, content, reshareNote)
+
+	aiResponse, err := invokeAi(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate post content: %v", err)
+	}
+
+	return &models.ShareCopy{
+		Blog:         post.blog(),
+		Posts:        splitPosts(aiResponse, targets),
+		GeneratedAt:  time.Now(),
+		PromptHash:   hashText(prompt),
+		ResponseHash: hashText(aiResponse),
+	}, nil
+}
+
+// sharePrompt asks for one post per platform, each under a tag splitPosts can find.
+// Platforms the user posts threads on get a thread of short posts instead.
+func sharePrompt(user *models.User, targets []Publisher, title, url, subtitle, brief, content, reshareNote string) string {
+	names := make([]string, 0, len(targets))
+	var format, limits strings.Builder
+	for _, publisher := range targets {
+		names = append(names, publisher.DisplayName())
+		format.WriteString(postTag(publisher) + "\n")
+		if threaded(user, publisher) {
+			format.WriteString(fmt.Sprintf("Your %s thread here: up to %d posts, each **%d characters or less**, separated by a line with only %s. Do not number the posts.\n\n", publisher.DisplayName(), models.XMaxThreadTweets, threadTweetChars, threadSeparator))
+			limits.WriteString(fmt.Sprintf("- Every post of the %s thread **MUST fit in %d characters**, a URL counts as %d.\n", publisher.DisplayName(), threadTweetChars, tcoLength))
+		} else if max := publisher.MaxChars(); max > 0 {
+			format.WriteString(fmt.Sprintf("Your %s post here (must be **%d characters or less**, including hashtags and URL)\n\n", publisher.DisplayName(), max))
+			limits.WriteString(fmt.Sprintf("- The %s post **MUST fit in %d characters including hashtags & URL**.\n", publisher.DisplayName(), max))
+		} else {
+			format.WriteString(fmt.Sprintf("Your %s post here (no strict length limit)\n\n", publisher.DisplayName()))
+		}
+	}
+
+	return fmt.Sprintf(
+		"Generate %d separate social media posts for this blog, one for each of: %s.\n\n"+
 			"Title: %s\n"+
 			"Url: %s\n"+
 			"Subtitle: %s\n"+
 			"Brief: %s\n"+
 			"Content snippet: %s\n\n"+
 			"--- Output Format ---\n"+
-			"[TWITTER]\n"+
-			"Your Twitter post here (must be **280 characters or less**, including hashtags and URL)\n\n"+
-			"[LINKEDIN]\n"+
-			"Your LinkedIn post here (no strict length limit)\n\n"+
+			"%s"+
 			"--- Additional Instructions ---\n"+
 			"- Keep the tone **engaging, conversational, and human**.\n"+
-			"- The Twitter post **MUST fit in 280 characters including hashtags & URL**.\n"+
-			"- Ensure the blog URL is included in both posts.\n"+
+			"%s"+
+			"- Ensure the blog URL is included in every post.\n"+
 			"- Do NOT add any extra commentary or explanations.\n"+
-			"- Include relevant **hashtags** in both posts.\n"+
-			"- Make the **LinkedIn post slightly longer**, but still concise and engaging.\n"+
-			"- Ensure that the response format is **EXACTLY as specified**, so it can be parsed programmatically.\n",
-		response.Data.Post.Title,
-		response.Data.Post.Url,
-		response.Data.Post.SubTitle,
-		response.Data.Post.Brief,
-		content,
+			"- Include relevant **hashtags** in every post.\n"+
+			"- Posts with a higher limit can be **slightly longer**, but still concise and engaging.\n"+
+			"- Ensure that the response format is **EXACTLY as specified**, so it can be parsed programmatically.\n"+
+			"%s",
+		len(targets), strings.Join(names, ", "),
+		title, url, subtitle, brief, content,
+		format.String(),
+		limits.String(),
+		reshareNote,
 	)
+}
 
-	aiResponse, err := invokeAi(prompt)
-	if err != nil {
-		return fmt.Errorf("failed to generate post content: %v", err)
+// splitPosts cuts the AI response at the platform tags, a post runs until the next tag.
+func splitPosts(aiResponse string, targets []Publisher) map[string]string {
+	type section struct {
+		platform string
+		start    int
+		end      int
 	}
+	var sections []section
+	for _, publisher := range targets {
+		tag := postTag(publisher)
+		if index := strings.Index(aiResponse, tag); index != -1 {
+			sections = append(sections, section{platform: publisher.Name(), start: index, end: index + len(tag)})
+		}
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].start < sections[j].start })
 
-	// Splitting the response
-	twitterTag := "[TWITTER]"
-	linkedinTag := "[LINKEDIN]"
-
-	twitterStart := strings.Index(aiResponse, twitterTag)
-	linkedinStart := strings.Index(aiResponse, linkedinTag)
-
-	// Extract Twitter content
-	var twitterPost string
-	if twitterStart != -1 && linkedinStart != -1 {
-		twitterPost = strings.TrimSpace(aiResponse[twitterStart+len(twitterTag) : linkedinStart])
-	} else if twitterStart != -1 {
-		twitterPost = strings.TrimSpace(aiResponse[twitterStart+len(twitterTag):])
+	posts := make(map[string]string, len(targets))
+	for i, sec := range sections {
+		next := len(aiResponse)
+		if i+1 < len(sections) {
+			next = sections[i+1].start
+		}
+		posts[sec.platform] = strings.TrimSpace(aiResponse[sec.end:next])
 	}
+	return posts
+}
 
-	// Extract LinkedIn content
-	var linkedinPost string
-	if linkedinStart != -1 {
-		linkedinPost = strings.TrimSpace(aiResponse[linkedinStart+len(linkedinTag):])
+// PublishShareCopy posts the generated copy to the given platforms and returns what
+// happened on each of them, it stops at the first platform that fails and returns a
+// PublishError.
+func PublishShareCopy(user *models.User, shareCopy *models.ShareCopy, platforms []string) ([]models.PlatformOutcome, error) {
+	if err := checkCanShare(user, platforms); err != nil {
+		return nil, err
 	}
 
-	for _, platform := range platforms {
-		switch platform {
-		case "linkedin":
-			err = linkedPostHandler(linkedinPost, user.LinkedInOauthKey)
-			if err != nil {
-				return fmt.Errorf("failed to post content to LinkedIn: %v", err)
-			}
-		case "twitter":
-			token := oauth1.NewToken(user.XOAuthToken, user.XOAuthSecret)
-			err = postTweetHandler(twitterPost, blogId, token)
-			if err != nil {
-				return fmt.Errorf("failed to post content to Twitter: %v", err)
+	var published []string
+	outcomes := make([]models.PlatformOutcome, 0, len(platforms))
+	for i, platform := range platforms {
+		publisher, _ := GetPublisher(platform)
+		postId, err := publisher.Publish(user, shareCopy)
+		if err != nil {
+			err = fmt.Errorf("failed to post content to %s: %v", publisher.DisplayName(), err)
+		}
+		if err != nil {
+			outcomes = append(outcomes, models.PlatformOutcome{Platform: platform, Status: models.TaskStatusFailed, Error: err.Error()})
+			for _, rest := range platforms[i+1:] {
+				outcomes = append(outcomes, models.PlatformOutcome{Platform: rest, Status: models.PlatformNotAttempted})
 			}
+			return outcomes, &PublishError{Platform: platform, Published: published, Err: err}
 		}
+		outcomes = append(outcomes, models.PlatformOutcome{Platform: platform, Status: models.TaskStatusShared, PostId: postId})
+		published = append(published, platform)
 	}
-	var isFound bool
+	return outcomes, nil
+}
+
+// RecordSharedBlog adds the blog to the user's shared blogs or bumps its shared time,
+// saving the user is left to the caller.
+func RecordSharedBlog(user *models.User, blog models.Blog, platforms []string) {
 	for i := range user.SharedBlogs {
-		if user.SharedBlogs[i].Id == response.Data.Post.Id {
+		if user.SharedBlogs[i].Id == blog.Id {
 			user.SharedBlogs[i].SharedTime = time.Now().Format(time.RFC3339)
-			err = repositories.UpdateUser(userId, user)
-			isFound = true
-			if err != nil {
-				return fmt.Errorf("failed to update user with shared blog: %v", err)
+			for _, platform := range platforms {
+				if !containsPlatform(user.SharedBlogs[i].Platforms, platform) {
+					user.SharedBlogs[i].Platforms = append(user.SharedBlogs[i].Platforms, platform)
+				}
 			}
-			break
+			return
 		}
 	}
-	if !isFound {
-		var newSharedBlog models.SharedBlog
-		newSharedBlog.Id = response.Data.Post.Id
-		newSharedBlog.Title = response.Data.Post.Title
-		newSharedBlog.Url = response.Data.Post.Url
-		newSharedBlog.CoverImage = models.Image{URL: response.Data.Post.CoverImage.Url}
-		newSharedBlog.Author = models.Author{Name: response.Data.Post.Author.Name}
-		newSharedBlog.ReadTimeInMinutes = response.Data.Post.ReadTimeInMinutes
-		newSharedBlog.SharedTime = time.Now().Format(time.RFC3339)
-		user.SharedBlogs = append(user.SharedBlogs, newSharedBlog)
-		err = repositories.UpdateUser(userId, user)
-		if err != nil {
-			return fmt.Errorf("failed to update user with shared blog: %v", err)
+
+	var newSharedBlog models.SharedBlog
+	newSharedBlog.Blog = blog
+	newSharedBlog.Platforms = append([]string{}, platforms...)
+	newSharedBlog.SharedTime = time.Now().Format(time.RFC3339)
+	user.SharedBlogs = append(user.SharedBlogs, newSharedBlog)
+}
+
+func containsPlatform(platforms []string, platform string) bool {
+	for _, p := range platforms {
+		if p == platform {
+			return true
 		}
 	}
-	return nil
+	return false
 }
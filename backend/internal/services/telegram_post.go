@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	// telegramMaxCaption is the longest photo caption, telegramMaxMessage the longest
+	// text message the Bot API takes
+	telegramMaxCaption = 1024
+	telegramMaxMessage = 4096
+)
+
+var (
+	telegramTokenPattern = regexp.MustCompile(`^[0-9]+:[A-Za-z0-9_-]+$`)
+	telegramChatPattern  = regexp.MustCompile(`^(@[A-Za-z][A-Za-z0-9_]{3,}|-?[0-9]+)$`)
+)
+
+// ValidateTelegramCredentials checks the shape of the bot token and channel id, the
+// token ends up in the path of every Bot API request.
+func ValidateTelegramCredentials(botToken, chatId string) error {
+	if !telegramTokenPattern.MatchString(botToken) {
+		return fmt.Errorf("invalid Telegram bot token")
+	}
+	if !telegramChatPattern.MatchString(chatId) {
+		return fmt.Errorf("invalid Telegram channel, use its @username or numeric id")
+	}
+	return nil
+}
+
+// callTelegram calls a Bot API method and returns its result.
+func callTelegram(botToken, method string, params map[string]interface{}) (json.RawMessage, error) {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s request: %v", method, err)
+	}
+	resp, err := httpClient.Post("https://api.telegram.org/bot"+botToken+"/"+method, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		// the error carries the URL, which carries the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to send %s request: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Ok          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response, status code: %d", method, resp.StatusCode)
+	}
+	if !result.Ok {
+		return nil, fmt.Errorf("%s failed, status code: %d, response: %s", method, resp.StatusCode, result.Description)
+	}
+	return result.Result, nil
+}
+
+// telegramCaption is the post in Telegram HTML, the blog title in bold above the
+// generated copy. Telegram counts the limit after parsing the markup, so the text is
+// trimmed before it is escaped.
+func telegramCaption(text string, blog models.Blog, limit int) string {
+	title := ""
+	// MaxChars keeps 200 characters of the caption for the title
+	if blog.Title != "" && len([]rune(blog.Title)) <= 198 {
+		title = blog.Title + "\n\n"
+	}
+	room := limit - len([]rune(title))
+	if runes := []rune(text); len(runes) > room {
+		text = string(runes[:room-3]) + "..."
+	}
+	if title == "" {
+		return html.EscapeString(text)
+	}
+	return "<b>" + html.EscapeString(blog.Title) + "</b>\n\n" + html.EscapeString(text)
+}
+
+// postTelegram sends the cover image with the caption, or a text message when the blog
+// has no cover, and returns the id of the message.
+func postTelegram(botToken, chatId, text string, blog models.Blog) (string, error) {
+	var result json.RawMessage
+	var err error
+	if blog.CoverImage.URL != "" {
+		result, err = callTelegram(botToken, "sendPhoto", map[string]interface{}{
+			"chat_id":    chatId,
+			"photo":      blog.CoverImage.URL,
+			"caption":    telegramCaption(text, blog, telegramMaxCaption),
+			"parse_mode": "HTML",
+		})
+	} else {
+		result, err = callTelegram(botToken, "sendMessage", map[string]interface{}{
+			"chat_id":    chatId,
+			"text":       telegramCaption(text, blog, telegramMaxMessage),
+			"parse_mode": "HTML",
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	var message struct {
+		MessageId int64 `json:"message_id"`
+	}
+	json.Unmarshal(result, &message)
+	return strconv.FormatInt(message.MessageId, 10), nil
+}
+
+// TestTelegram sends a message saying the channel is connected, it fails when the bot
+// can't post to the channel.
+func TestTelegram(botToken, chatId string) error {
+	_, err := callTelegram(botToken, "sendMessage", map[string]interface{}{
+		"chat_id": chatId,
+		"text":    "Social Scribe is connected, your blog shares will be announced here.",
+	})
+	return err
+}
+
+type telegramPublisher struct{}
+
+func init() {
+	RegisterPublisher(telegramPublisher{})
+}
+
+func (telegramPublisher) Name() string        { return "telegram" }
+func (telegramPublisher) DisplayName() string { return "Telegram" }
+
+// MaxChars leaves room in the photo caption for the title above the copy.
+func (telegramPublisher) MaxChars() int { return telegramMaxCaption - 200 }
+
+func (telegramPublisher) Capabilities() Capabilities {
+	return Capabilities{Delete: true, Images: true}
+}
+
+func (telegramPublisher) HasCredentials(user *models.User) bool {
+	return user.TelegramVerified && user.TelegramBotToken != "" && user.TelegramChatId != ""
+}
+
+func (telegramPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	return postTelegram(user.TelegramBotToken, user.TelegramChatId, shareCopy.Posts["telegram"], shareCopy.Blog)
+}
+
+func (telegramPublisher) Delete(user *models.User, postId string) error {
+	messageId, err := strconv.ParseInt(postId, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Telegram message id %s", postId)
+	}
+	_, err = callTelegram(user.TelegramBotToken, "deleteMessage", map[string]interface{}{
+		"chat_id":    user.TelegramChatId,
+		"message_id": messageId,
+	})
+	return err
+}
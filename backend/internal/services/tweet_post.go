@@ -2,43 +2,66 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/dghubble/oauth1"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"social-scribe/backend/internal/models"
+	"strings"
 )
 
+// tweetMaxAltText is the longest alt text X takes for an image
+const tweetMaxAltText = 1000
+
 var twitterConfig = &oauth1.Config{}
 
 func InitTwitterConfig(config *oauth1.Config) {
 	twitterConfig = config
 }
 
-func postTweetHandler(message string, blogId string, userToken *oauth1.Token) error {
-	// Trim tweet if it exceeds 280 chars
-	runes := []rune(message)
-	if len(runes) > 280 {
+// twitterClient signs requests with the user's token, it has the timeout of httpClient
+// since oauth1 only takes the transport of the client in the context.
+func twitterClient(userToken *oauth1.Token) *http.Client {
+	client := twitterConfig.Client(context.WithValue(oauth1.NoContext, oauth1.HTTPClient, httpClient), userToken)
+	client.Timeout = httpClient.Timeout
+	return client
+}
+
+// postTweetHandler posts the tweet, as a reply when replyTo is set and with the media
+// when mediaId is set, and returns its id.
+func postTweetHandler(message string, blogId string, userToken *oauth1.Token, replyTo string, mediaId string) (string, error) {
+	// Trim tweet if it exceeds 280 chars, counted the way X counts links
+	if tweetLength(message) > tweetMaxChars {
 		log.Printf("[WARN] Tweet for blog id %s exceeds 280 characters, trimming message", blogId)
-		message = string(runes[:277]) + "..."
+		message = trimTweet(message)
 	}
 
-	client := twitterConfig.Client(oauth1.NoContext, userToken)
+	client := twitterClient(userToken)
 	tweetURL := "https://api.twitter.com/2/tweets"
 
 	// Create JSON payload
-	payload, err := json.Marshal(map[string]interface{}{
+	body := map[string]interface{}{
 		"text": message,
-	})
+	}
+	if replyTo != "" {
+		body["reply"] = map[string]string{"in_reply_to_tweet_id": replyTo}
+	}
+	if mediaId != "" {
+		body["media"] = map[string][]string{"media_ids": {mediaId}}
+	}
+	payload, err := json.Marshal(body)
 	if err != nil {
 		log.Printf("[ERROR] Failed to marshal tweet payload for blog id %s: %s", blogId, err)
-		return err
+		return "", err
 	}
 
 	req, err := http.NewRequest("POST", tweetURL, bytes.NewBuffer(payload))
 	if err != nil {
 		log.Printf("[ERROR] Failed to create request for blog id %s: %s", blogId, err)
-		return err
+		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -46,7 +69,7 @@ func postTweetHandler(message string, blogId string, userToken *oauth1.Token) er
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("[ERROR] Failed to post tweet for blog id %s: %s", blogId, err)
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -55,9 +78,157 @@ func postTweetHandler(message string, blogId string, userToken *oauth1.Token) er
 		var errResp map[string]interface{}
 		json.NewDecoder(resp.Body).Decode(&errResp)
 		log.Printf("[ERROR] Twitter API response: %v", errResp)
-		return errors.New("failed to post tweet: " + resp.Status)
+		return "", errors.New("failed to post tweet: " + resp.Status)
+	}
+
+	var created struct {
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
 	}
+	json.NewDecoder(resp.Body).Decode(&created)
 
 	log.Printf("[INFO] Blog with ID %s shared on X(Twitter) successfully", blogId)
+	return created.Data.Id, nil
+}
+
+// uploadTweetMedia uploads the image and sets its alt text, it returns the media id a
+// tweet attaches it with.
+func uploadTweetMedia(image []byte, alt string, userToken *oauth1.Token) (string, error) {
+	client := twitterClient(userToken)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("media", "cover")
+	if err != nil {
+		return "", err
+	}
+	part.Write(image)
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", "https://upload.twitter.com/1.1/media/upload.json", &form)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", errors.New("failed to upload media: " + resp.Status)
+	}
+	var uploaded struct {
+		MediaId string `json:"media_id_string"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil || uploaded.MediaId == "" {
+		return "", errors.New("failed to parse media upload response")
+	}
+
+	// the image is attached without alt text rather than not at all
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"media_id": uploaded.MediaId,
+		"alt_text": map[string]string{"text": altText(alt, tweetMaxAltText)},
+	})
+	metaReq, err := http.NewRequest("POST", "https://upload.twitter.com/1.1/media/metadata/create.json", bytes.NewBuffer(metadata))
+	if err != nil {
+		return uploaded.MediaId, nil
+	}
+	metaReq.Header.Set("Content-Type", "application/json")
+	metaResp, err := client.Do(metaReq)
+	if err != nil {
+		log.Printf("[WARN] Failed to set alt text of media %s: %s", uploaded.MediaId, err)
+		return uploaded.MediaId, nil
+	}
+	defer metaResp.Body.Close()
+	if metaResp.StatusCode != http.StatusOK && metaResp.StatusCode != http.StatusCreated {
+		log.Printf("[WARN] Failed to set alt text of media %s: %s", uploaded.MediaId, metaResp.Status)
+	}
+	return uploaded.MediaId, nil
+}
+
+// tweetCoverMedia uploads the blog's cover image, an empty id leaves the tweet
+// text-only when there is no cover or it can't be uploaded.
+func tweetCoverMedia(blog models.Blog, userToken *oauth1.Token) string {
+	if blog.CoverImage.URL == "" {
+		return ""
+	}
+	image, _, err := fetchCoverImage(blog.CoverImage.URL, coverImageMaxBytes)
+	if err == nil {
+		var mediaId string
+		if mediaId, err = uploadTweetMedia(image, blog.Title, userToken); err == nil {
+			return mediaId
+		}
+	}
+	log.Printf("[WARN] Sharing blog id %s on X without its cover image: %s", blog.Id, err)
+	return ""
+}
+
+func deleteTweetHandler(tweetId string, userToken *oauth1.Token) error {
+	client := twitterClient(userToken)
+	req, err := http.NewRequest("DELETE", "https://api.twitter.com/2/tweets/"+tweetId, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("failed to delete tweet: " + resp.Status)
+	}
+	return nil
+}
+
+type twitterPublisher struct{}
+
+func init() {
+	RegisterPublisher(twitterPublisher{})
+}
+
+func (twitterPublisher) Name() string        { return "twitter" }
+func (twitterPublisher) DisplayName() string { return "Twitter (X)" }
+func (twitterPublisher) MaxChars() int       { return 280 }
+
+func (twitterPublisher) Capabilities() Capabilities {
+	return Capabilities{Delete: true, Images: true, Threads: true}
+}
+
+func (twitterPublisher) ThreadsEnabled(user *models.User) bool {
+	return user.XThread != nil && user.XThread.Enabled
+}
+
+func (twitterPublisher) HasCredentials(user *models.User) bool {
+	return user.XVerified
+}
+
+func (twitterPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	token := oauth1.NewToken(user.XOAuthToken, user.XOAuthSecret)
+	post := shareCopy.Posts["twitter"]
+	mediaId := tweetCoverMedia(shareCopy.Blog, token)
+	if !threaded(user, twitterPublisher{}) {
+		return postTweetHandler(post, shareCopy.Blog.Id, token, "", mediaId)
+	}
+	tweets := splitThread(post, shareCopy.Blog.Url, user.XThread.LinkPlacement, shareCopy.Blog.Id)
+	if len(tweets) == 1 {
+		return postTweetHandler(tweets[0], shareCopy.Blog.Id, token, "", mediaId)
+	}
+	return postThread(tweets, shareCopy.Blog.Id, token, mediaId)
+}
+
+// Delete takes down the tweet, or every tweet of a thread, whose ids postThread joined
+// with commas.
+func (twitterPublisher) Delete(user *models.User, postId string) error {
+	token := oauth1.NewToken(user.XOAuthToken, user.XOAuthSecret)
+	ids := strings.Split(postId, ",")
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := deleteTweetHandler(ids[i], token); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dghubble/oauth1"
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	tweetMaxChars = 280
+	// tcoLength is what X counts any link as, links are shortened to t.co
+	tcoLength = 23
+	// threadTweetChars leaves room after the text of a tweet for its number, " 10/10"
+	threadTweetChars = tweetMaxChars - 6
+	// threadSeparator is the line the AI puts between the tweets of a thread
+	threadSeparator = "---"
+)
+
+var tweetLinkPattern = regexp.MustCompile(`https?://\S+`)
+
+// tweetLength counts the text the way X does, any link is as long as a t.co link.
+func tweetLength(text string) int {
+	length := utf8.RuneCountInString(text)
+	for _, link := range tweetLinkPattern.FindAllString(text, -1) {
+		length += tcoLength - utf8.RuneCountInString(link)
+	}
+	return length
+}
+
+// trimTweet cuts the text so it fits in a tweet with the "..." it ends with.
+func trimTweet(text string) string {
+	runes := []rune(text)
+	for over := tweetLength(string(runes)) + 3 - tweetMaxChars; over > 0; over = tweetLength(string(runes)) + 3 - tweetMaxChars {
+		runes = runes[:len(runes)-min(over, len(runes))]
+	}
+	return string(runes) + "..."
+}
+
+// chunkWords packs the words into as few tweets as fit in the limit.
+func chunkWords(words []string, limit int) []string {
+	var tweets []string
+	current := ""
+	for _, word := range words {
+		// a word no tweet fits, only ever a broken AI answer, is cut
+		if tweetLength(word) > limit {
+			word = string([]rune(word)[:limit])
+		}
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if tweetLength(candidate) <= limit {
+			current = candidate
+			continue
+		}
+		tweets = append(tweets, current)
+		current = word
+	}
+	if current != "" {
+		tweets = append(tweets, current)
+	}
+	return tweets
+}
+
+// splitThread turns the copy into the tweets of a thread. The AI separates the tweets
+// it wrote with threadSeparator lines, anything still too long is split between words.
+// The blog link is taken out of the text and put in the first or last tweet.
+func splitThread(text, link, placement, blogId string) []string {
+	var tweets []string
+	for _, part := range strings.Split(text, "\n") {
+		if strings.TrimSpace(part) == threadSeparator {
+			tweets = append(tweets, "")
+		} else if len(tweets) == 0 {
+			tweets = append(tweets, part)
+		} else {
+			tweets[len(tweets)-1] += "\n" + part
+		}
+	}
+
+	var content []string
+	for _, part := range tweets {
+		if link != "" {
+			part = strings.ReplaceAll(part, link, "")
+		}
+		content = append(content, chunkWords(strings.Fields(part), threadTweetChars)...)
+	}
+	if len(content) > models.XMaxThreadTweets {
+		log.Printf("[WARN] Thread for blog id %s has %d tweets, posting the first %d", blogId, len(content), models.XMaxThreadTweets)
+		content = content[:models.XMaxThreadTweets]
+	}
+
+	if link != "" {
+		content = placeLink(content, link, placement)
+	}
+	if len(content) > models.XMaxThreadTweets {
+		// the breaks the AI chose leave no room for the link, the words are packed
+		// tightly instead
+		content = chunkWords(strings.Fields(strings.Join(content, " ")), threadTweetChars)
+	}
+	if len(content) > models.XMaxThreadTweets {
+		log.Printf("[WARN] Thread for blog id %s has no room left for the link, shortening its last tweet", blogId)
+		content = content[:models.XMaxThreadTweets]
+		if placement == models.XLinkLast {
+			last := len(content) - 1
+			content[last] = withLink(strings.ReplaceAll(content[last], link, ""), link)
+		}
+	}
+	if len(content) > 1 {
+		for i := range content {
+			content[i] += fmt.Sprintf(" %d/%d", i+1, len(content))
+		}
+	}
+	return content
+}
+
+// placeLink adds the link to the first or last tweet, moving words of the first tweet to
+// the second, or giving the link a tweet of its own at the end, when it doesn't fit.
+// The thread can end up a tweet longer than the limit, splitThread packs it again.
+func placeLink(tweets []string, link, placement string) []string {
+	if len(tweets) == 0 {
+		return []string{link}
+	}
+	linkRoom := 1 + tcoLength
+	if placement == models.XLinkLast {
+		last := len(tweets) - 1
+		if tweetLength(tweets[last])+linkRoom <= threadTweetChars {
+			tweets[last] += " " + link
+			return tweets
+		}
+		return append(tweets, link)
+	}
+
+	words := strings.Fields(tweets[0])
+	var moved []string
+	for len(words) > 0 && tweetLength(strings.Join(words, " "))+linkRoom > threadTweetChars {
+		moved = append([]string{words[len(words)-1]}, moved...)
+		words = words[:len(words)-1]
+	}
+	tweets[0] = strings.TrimSpace(strings.Join(words, " ") + " " + link)
+	if len(moved) > 0 {
+		tweets = append(tweets[:1], append([]string{strings.Join(moved, " ")}, tweets[1:]...)...)
+	}
+	return tweets
+}
+
+// withLink ends the tweet with the link, dropping the words at its end that leave no
+// room for it.
+func withLink(tweet, link string) string {
+	words := strings.Fields(tweet)
+	for len(words) > 0 && tweetLength(strings.Join(words, " "))+1+tcoLength > threadTweetChars {
+		words = words[:len(words)-1]
+	}
+	return strings.TrimSpace(strings.Join(words, " ") + " " + link)
+}
+
+// postThread posts the tweets as a chain of replies, the media goes on the first one,
+// and returns their ids joined by commas. When a tweet fails the ones already posted
+// are taken down again, a half thread reads worse than none.
+func postThread(tweets []string, blogId string, userToken *oauth1.Token, mediaId string) (string, error) {
+	var ids []string
+	for _, tweet := range tweets {
+		replyTo, media := "", mediaId
+		if len(ids) > 0 {
+			replyTo, media = ids[len(ids)-1], ""
+		}
+		id, err := postTweetHandler(tweet, blogId, userToken, replyTo, media)
+		if err != nil {
+			for i := len(ids) - 1; i >= 0; i-- {
+				if deleteErr := deleteTweetHandler(ids[i], userToken); deleteErr != nil {
+					log.Printf("[ERROR] Failed to delete tweet %s of the broken thread for blog id %s: %s", ids[i], blogId, deleteErr)
+				}
+			}
+			return "", fmt.Errorf("failed to post tweet %d of %d: %w", len(ids)+1, len(tweets), err)
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, ","), nil
+}
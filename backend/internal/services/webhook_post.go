@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"social-scribe/backend/internal/models"
+)
+
+const (
+	WebhookDiscord = "discord"
+	WebhookSlack   = "slack"
+	// discordMaxContent is the longest message content a Discord webhook takes
+	discordMaxContent = 2000
+)
+
+// WebhookKind tells which chat an incoming webhook URL posts to. Only Discord and
+// Slack webhooks are accepted, we post to whatever URL the user gives us.
+func WebhookKind(webhookURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(webhookURL))
+	if err != nil || parsed.Scheme != "https" {
+		return "", fmt.Errorf("invalid webhook URL")
+	}
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case (host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")) &&
+		strings.HasPrefix(parsed.Path, "/api/webhooks/"):
+		return WebhookDiscord, nil
+	case host == "hooks.slack.com" && strings.HasPrefix(parsed.Path, "/services/"):
+		return WebhookSlack, nil
+	}
+	return "", fmt.Errorf("only Discord and Slack incoming webhooks are supported")
+}
+
+// MaskWebhookURL hides the token part of the URL, anyone holding the full URL can post
+// to the channel.
+func MaskWebhookURL(webhookURL string) string {
+	if webhookURL == "" {
+		return ""
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/…"
+}
+
+// webhookDescription is the byline of the blog, the author and read time.
+func webhookDescription(blog models.Blog) string {
+	var parts []string
+	if blog.Author.Name != "" {
+		parts = append(parts, "By "+blog.Author.Name)
+	}
+	if blog.ReadTimeInMinutes > 0 {
+		parts = append(parts, fmt.Sprintf("%d min read", blog.ReadTimeInMinutes))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// discordPayload is the message with an embed card of the blog.
+func discordPayload(text string, blog models.Blog) map[string]interface{} {
+	if runes := []rune(text); len(runes) > discordMaxContent {
+		text = string(runes[:discordMaxContent-3]) + "..."
+	}
+	embed := map[string]interface{}{
+		"title": blog.Title,
+		// Hashnode's blue
+		"color": 0x2962FF,
+	}
+	if blog.Url != "" {
+		embed["url"] = blog.Url
+	}
+	if blog.Author.Name != "" {
+		embed["author"] = map[string]string{"name": blog.Author.Name}
+	}
+	if blog.ReadTimeInMinutes > 0 {
+		embed["footer"] = map[string]string{"text": fmt.Sprintf("%d min read", blog.ReadTimeInMinutes)}
+	}
+	if blog.CoverImage.URL != "" {
+		embed["image"] = map[string]string{"url": blog.CoverImage.URL}
+	}
+	return map[string]interface{}{
+		"content": text,
+		"embeds":  []interface{}{embed},
+	}
+}
+
+// slackPayload is the message as Block Kit blocks, text is the fallback notifications
+// show.
+func slackPayload(text string, blog models.Blog) map[string]interface{} {
+	title := "*" + slackEscape(blog.Title) + "*"
+	if blog.Url != "" {
+		title = fmt.Sprintf("*<%s|%s>*", blog.Url, slackEscape(blog.Title))
+	}
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": text},
+		},
+		map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": title},
+		},
+	}
+	if blog.CoverImage.URL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":      "image",
+			"image_url": blog.CoverImage.URL,
+			"alt_text":  blog.Title,
+		})
+	}
+	if description := webhookDescription(blog); description != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{map[string]string{"type": "mrkdwn", "text": slackEscape(description)}},
+		})
+	}
+	return map[string]interface{}{
+		"text":   text,
+		"blocks": blocks,
+	}
+}
+
+// slackEscape escapes the characters Slack's mrkdwn treats as markup.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// postWebhook sends the announcement and returns the id of the Discord message, Slack
+// webhooks don't give one back.
+func postWebhook(webhookURL, text string, blog models.Blog) (string, error) {
+	kind, err := WebhookKind(webhookURL)
+	if err != nil {
+		return "", err
+	}
+	target, _ := url.Parse(webhookURL)
+	var payload map[string]interface{}
+	if kind == WebhookDiscord {
+		payload = discordPayload(text, blog)
+		// without wait Discord answers 204 and the message id is lost
+		query := target.Query()
+		query.Set("wait", "true")
+		target.RawQuery = query.Encode()
+	} else {
+		payload = slackPayload(text, blog)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal webhook message: %v", err)
+	}
+
+	resp, err := httpClient.Post(target.String(), "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to send webhook message: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to post %s webhook message, status code: %d, response: %s", kind, resp.StatusCode, respBody)
+	}
+	if kind != WebhookDiscord {
+		return "", nil
+	}
+	var created struct {
+		Id string `json:"id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Id, nil
+}
+
+// TestWebhook posts a message saying the channel is connected, it fails when the
+// webhook doesn't take it.
+func TestWebhook(webhookURL string) error {
+	_, err := postWebhook(webhookURL, "Social Scribe is connected, your blog shares will be announced here.", models.Blog{
+		Title: "Social Scribe",
+	})
+	return err
+}
+
+type webhookPublisher struct{}
+
+func init() {
+	RegisterPublisher(webhookPublisher{})
+}
+
+func (webhookPublisher) Name() string        { return "webhook" }
+func (webhookPublisher) DisplayName() string { return "Discord/Slack" }
+func (webhookPublisher) MaxChars() int       { return discordMaxContent }
+
+func (webhookPublisher) Capabilities() Capabilities {
+	return Capabilities{Images: true}
+}
+
+func (webhookPublisher) HasCredentials(user *models.User) bool {
+	return user.WebHookUrl != ""
+}
+
+func (webhookPublisher) Publish(user *models.User, shareCopy *models.ShareCopy) (string, error) {
+	return postWebhook(user.WebHookUrl, shareCopy.Posts["webhook"], shareCopy.Blog)
+}
+
+func (webhookPublisher) Delete(user *models.User, postId string) error {
+	return fmt.Errorf("webhook messages can't be deleted")
+}
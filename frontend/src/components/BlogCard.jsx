@@ -33,6 +33,10 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
   const [shareOptions, setShareOptions] = useState({
     linkedin: false,
     x: false,
+    mastodon: false,
+    bluesky: false,
+    webhook: false,
+    telegram: false,
   });
 
   const handleShareClick = (event) => setAnchorEl(event.currentTarget);
@@ -45,6 +49,10 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
     const platforms = [];
     if (shareOptions.linkedin) platforms.push('linkedin');
     if (shareOptions.x) platforms.push('twitter');
+    if (shareOptions.mastodon) platforms.push('mastodon');
+    if (shareOptions.bluesky) platforms.push('bluesky');
+    if (shareOptions.webhook) platforms.push('webhook');
+    if (shareOptions.telegram) platforms.push('telegram');
 
     if (platforms.length === 0) {
       toast.warning('Please select at least one platform to share!');
@@ -92,7 +100,14 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
 
   const handleOpenSchedule = () => {
     setSelectedDate(dayjs());
-    setShareOptions({ linkedin: false, x: false });
+    setShareOptions({
+      linkedin: false,
+      x: false,
+      mastodon: false,
+      bluesky: false,
+      webhook: false,
+      telegram: false,
+    });
     setOpenSchedule(true);
   };
 
@@ -113,6 +128,10 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
     const platforms = [];
     if (shareOptions.linkedin) platforms.push('linkedin');
     if (shareOptions.x) platforms.push('twitter');
+    if (shareOptions.mastodon) platforms.push('mastodon');
+    if (shareOptions.bluesky) platforms.push('bluesky');
+    if (shareOptions.webhook) platforms.push('webhook');
+    if (shareOptions.telegram) platforms.push('telegram');
 
     if (platforms.length === 0) {
       toast.warning('Please select at least one platform to schedule!');
@@ -169,7 +188,7 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
   };
 
   const handleCancelSchedule = async () => {
-    const payload = { id: blog.id };
+    const payload = { id: blog.id, task_id: blog.task_id };
 
     try {
       // Initial cancel request
@@ -208,6 +227,52 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
     }
   };
 
+  const handleCrossPostDevto = async () => {
+    const payload = { id: blog.id, published: true };
+
+    try {
+      let response = await fetch(apiUrl + '/api/v1/blogs/cross-post/devto', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-Csrf-Token': csrfToken,
+        },
+        credentials: 'include',
+        body: JSON.stringify(payload),
+      });
+
+      // Retry on 403 after refreshing token
+      if (response.status === 403) {
+        await checkLoggedIn();
+        response = await fetch(apiUrl + '/api/v1/blogs/cross-post/devto', {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json',
+            'X-Csrf-Token': csrfToken,
+          },
+          credentials: 'include',
+          body: JSON.stringify(payload),
+        });
+      }
+
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      const data = await response.json();
+      toast.success(
+        <span>
+          Cross-posted to{' '}
+          <a href={data.remote_url} target="_blank" rel="noreferrer">
+            Dev.to
+          </a>
+        </span>
+      );
+    } catch (error) {
+      console.error('Error cross-posting the blog:', error.message);
+      toast.error(error.message || 'Failed to cross-post to Dev.to. Is Dev.to connected?');
+    }
+  };
+
   const openPopover = Boolean(anchorEl);
   const popoverId = openPopover ? 'share-popover' : undefined;
 
@@ -352,6 +417,50 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
                   }
                   label={<Typography sx={{ color: 'white' }}>X</Typography>}
                 />
+                <FormControlLabel
+                  control={
+                    <Checkbox
+                      sx={{ color: 'white' }}
+                      checked={shareOptions.mastodon}
+                      onChange={handleShareOptionChange}
+                      name="mastodon"
+                    />
+                  }
+                  label={<Typography sx={{ color: 'white' }}>Mastodon</Typography>}
+                />
+                <FormControlLabel
+                  control={
+                    <Checkbox
+                      sx={{ color: 'white' }}
+                      checked={shareOptions.bluesky}
+                      onChange={handleShareOptionChange}
+                      name="bluesky"
+                    />
+                  }
+                  label={<Typography sx={{ color: 'white' }}>Bluesky</Typography>}
+                />
+                <FormControlLabel
+                  control={
+                    <Checkbox
+                      sx={{ color: 'white' }}
+                      checked={shareOptions.webhook}
+                      onChange={handleShareOptionChange}
+                      name="webhook"
+                    />
+                  }
+                  label={<Typography sx={{ color: 'white' }}>Discord/Slack</Typography>}
+                />
+                <FormControlLabel
+                  control={
+                    <Checkbox
+                      sx={{ color: 'white' }}
+                      checked={shareOptions.telegram}
+                      onChange={handleShareOptionChange}
+                      name="telegram"
+                    />
+                  }
+                  label={<Typography sx={{ color: 'white' }}>Telegram</Typography>}
+                />
                 <Button
                   variant="contained"
                   color="primary"
@@ -383,6 +492,22 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
               Schedule
             </Button>
 
+            <Button
+              variant="outlined"
+              sx={{
+                borderColor: 'white',
+                color: 'white',
+                fontSize: '12px',
+                textTransform: 'none',
+                minWidth: '60px',
+                padding: '5px',
+                '&:hover': { borderColor: '#e0e0e0' },
+              }}
+              onClick={handleCrossPostDevto}
+            >
+              Dev.to
+            </Button>
+
             <Modal open={openSchedule} onClose={handleCloseSchedule}>
               <Box
                 sx={{
@@ -451,6 +576,50 @@ const BlogCard = ({ blog, apiUrl, checkLoggedIn }) => {
                     }
                     label={<Typography sx={{ color: 'white' }}>X</Typography>}
                   />
+                  <FormControlLabel
+                    control={
+                      <Checkbox
+                        sx={{ color: 'white' }}
+                        checked={shareOptions.mastodon}
+                        onChange={handleShareOptionChange}
+                        name="mastodon"
+                      />
+                    }
+                    label={<Typography sx={{ color: 'white' }}>Mastodon</Typography>}
+                  />
+                  <FormControlLabel
+                    control={
+                      <Checkbox
+                        sx={{ color: 'white' }}
+                        checked={shareOptions.bluesky}
+                        onChange={handleShareOptionChange}
+                        name="bluesky"
+                      />
+                    }
+                    label={<Typography sx={{ color: 'white' }}>Bluesky</Typography>}
+                  />
+                  <FormControlLabel
+                    control={
+                      <Checkbox
+                        sx={{ color: 'white' }}
+                        checked={shareOptions.webhook}
+                        onChange={handleShareOptionChange}
+                        name="webhook"
+                      />
+                    }
+                    label={<Typography sx={{ color: 'white' }}>Discord/Slack</Typography>}
+                  />
+                  <FormControlLabel
+                    control={
+                      <Checkbox
+                        sx={{ color: 'white' }}
+                        checked={shareOptions.telegram}
+                        onChange={handleShareOptionChange}
+                        name="telegram"
+                      />
+                    }
+                    label={<Typography sx={{ color: 'white' }}>Telegram</Typography>}
+                  />
                 </Box>
                 <Button
                   variant="contained"
@@ -9,6 +9,18 @@ import { useNavigate } from 'react-router-dom';
 const VerificationPage = ({ user, setUser, apiUrl, csrfToken, checkLoggedIn }) => {
   const [twitterConnected] = useState(user?.x_verified);
   const [linkedinConnected] = useState(user?.linkedin_verified);
+  const [mastodonConnected] = useState(user?.mastodon_verified);
+  const [mastodonInstance, setMastodonInstance] = useState(user?.mastodon_instance || '');
+  const [blueskyConnected, setBlueskyConnected] = useState(user?.bluesky_verified);
+  const [blueskyHandle, setBlueskyHandle] = useState(user?.bluesky_handle || '');
+  const [blueskyAppPassword, setBlueskyAppPassword] = useState('');
+  const [webhookConnected, setWebhookConnected] = useState(!!user?.webhook_url);
+  const [webhookUrl, setWebhookUrl] = useState('');
+  const [telegramConnected, setTelegramConnected] = useState(user?.telegram_verified);
+  const [telegramBotToken, setTelegramBotToken] = useState('');
+  const [telegramChatId, setTelegramChatId] = useState(user?.telegram_chat_id || '');
+  const [devtoConnected, setDevtoConnected] = useState(user?.devto_verified);
+  const [devtoApiKey, setDevtoApiKey] = useState('');
   const [hashnodeVerified, setHashnodeVerified] = useState(user?.hashnode_verified);
   const [hashnodeApiKey, setHashnodeApiKey] = useState('');
   const [disabled, setDisabled] = useState(true);
@@ -27,6 +39,144 @@ const VerificationPage = ({ user, setUser, apiUrl, csrfToken, checkLoggedIn }) =
     window.location.href = apiUrl + '/api/v1/user/connect-linkedin';
   };
 
+  const handleMastodonConnect = () => {
+    if (!mastodonInstance) {
+      toast.warning('Enter the Mastodon server you are on, like mastodon.social');
+      return;
+    }
+    window.location.href =
+      apiUrl + '/api/v1/user/connect-mastodon?instance=' + encodeURIComponent(mastodonInstance);
+  };
+
+  const handleBlueskyConnect = async () => {
+    if (!blueskyHandle || !blueskyAppPassword) {
+      toast.warning('Enter your Bluesky handle and an app password');
+      return;
+    }
+
+    try {
+      const response = await fetch(apiUrl + '/api/v1/user/connect-bluesky', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-Csrf-Token': csrfToken,
+        },
+        body: JSON.stringify({
+          handle: blueskyHandle,
+          app_password: blueskyAppPassword,
+        }),
+        credentials: 'include',
+      });
+      if (response.ok) {
+        setBlueskyConnected(true);
+        setBlueskyAppPassword('');
+        toast.success('Bluesky connected');
+      } else {
+        toast.error('Could not sign in to Bluesky, check the handle and app password');
+      }
+    } catch (error) {
+      toast.error('Could not connect Bluesky');
+    }
+  };
+
+  const handleTelegramConnect = async () => {
+    if (!telegramBotToken || !telegramChatId) {
+      toast.warning('Enter the bot token and the channel, like @mychannel');
+      return;
+    }
+
+    try {
+      const response = await fetch(apiUrl + '/api/v1/user/connect-telegram', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-Csrf-Token': csrfToken,
+        },
+        body: JSON.stringify({
+          bot_token: telegramBotToken,
+          chat_id: telegramChatId,
+        }),
+        credentials: 'include',
+      });
+      if (response.ok) {
+        setTelegramConnected(true);
+        setTelegramBotToken('');
+        toast.success('Telegram connected, check the channel for a test message');
+      } else {
+        toast.error(await response.text());
+      }
+    } catch (error) {
+      toast.error('Could not connect Telegram');
+    }
+  };
+
+  const handleDevtoConnect = async () => {
+    if (!devtoApiKey) {
+      return;
+    }
+
+    try {
+      const response = await fetch(apiUrl + '/api/v1/user/connect-devto', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-Csrf-Token': csrfToken,
+        },
+        body: JSON.stringify({ api_key: devtoApiKey }),
+        credentials: 'include',
+      });
+      if (response.ok) {
+        setDevtoConnected(true);
+        setDevtoApiKey('');
+        toast.success('Dev.to connected');
+      } else {
+        toast.error(await response.text());
+      }
+    } catch (error) {
+      toast.error('Could not connect Dev.to');
+    }
+  };
+
+  const handleWebhookSave = async (url) => {
+    try {
+      const response = await fetch(apiUrl + '/api/v1/user/webhook', {
+        method: 'PUT',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-Csrf-Token': csrfToken,
+        },
+        body: JSON.stringify({ url }),
+        credentials: 'include',
+      });
+      if (response.ok) {
+        setWebhookConnected(!!url);
+        setWebhookUrl('');
+        toast.success(url ? 'Webhook connected, check the channel for a test message' : 'Webhook removed');
+      } else {
+        toast.error(await response.text());
+      }
+    } catch (error) {
+      toast.error('Could not save the webhook');
+    }
+  };
+
+  const handleWebhookTest = async () => {
+    try {
+      const response = await fetch(apiUrl + '/api/v1/user/webhook/test', {
+        method: 'POST',
+        headers: { 'X-Csrf-Token': csrfToken },
+        credentials: 'include',
+      });
+      if (response.ok) {
+        toast.success('Test message sent');
+      } else {
+        toast.error(await response.text());
+      }
+    } catch (error) {
+      toast.error('Could not send the test message');
+    }
+  };
+
   const handleHashnodeVerify = async () => {
     if (!hashnodeApiKey) {
       return;
@@ -179,6 +329,10 @@ const VerificationPage = ({ user, setUser, apiUrl, csrfToken, checkLoggedIn }) =
       ...user,
       twitterConnected,
       linkedinConnected,
+      mastodonConnected,
+      blueskyConnected,
+      webhookConnected,
+      telegramConnected,
       hashnodeVerified,
       emailVerified,
     });
@@ -186,11 +340,29 @@ const VerificationPage = ({ user, setUser, apiUrl, csrfToken, checkLoggedIn }) =
   };
 
   useEffect(() => {
-    if (hashnodeVerified && emailVerified && (linkedinConnected || twitterConnected)) {
+    if (
+      hashnodeVerified &&
+      emailVerified &&
+      (linkedinConnected ||
+        twitterConnected ||
+        mastodonConnected ||
+        blueskyConnected ||
+        webhookConnected ||
+        telegramConnected)
+    ) {
       setDisabled(false);
       user.verified = true;
     }
-  }, [hashnodeVerified, linkedinConnected, twitterConnected, emailVerified]);
+  }, [
+    hashnodeVerified,
+    linkedinConnected,
+    twitterConnected,
+    mastodonConnected,
+    blueskyConnected,
+    webhookConnected,
+    telegramConnected,
+    emailVerified,
+  ]);
 
   return (
     <Box
@@ -261,6 +433,175 @@ const VerificationPage = ({ user, setUser, apiUrl, csrfToken, checkLoggedIn }) =
           </Box>
         </Box>
 
+        {/* Mastodon Connect */}
+        <Box sx={{ marginBottom: '1.5rem' }}>
+          <Box display="flex" alignItems="center" justifyContent="space-between">
+            <Box display="flex" alignItems="center" gap="0.5rem">
+              <Typography>Connect Mastodon</Typography>
+              {mastodonConnected ? <CheckCircleIcon color="success" /> : <></>}
+            </Box>
+            {mastodonConnected ? (
+              <Button variant="contained" color="error">
+                Disconnect
+              </Button>
+            ) : (
+              <Button variant="contained" onClick={handleMastodonConnect}>
+                Connect
+              </Button>
+            )}
+          </Box>
+          {!mastodonConnected && (
+            <TextField
+              fullWidth
+              size="small"
+              placeholder="mastodon.social"
+              value={mastodonInstance}
+              onChange={(e) => setMastodonInstance(e.target.value)}
+              sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+            />
+          )}
+        </Box>
+
+        {/* Bluesky Connect */}
+        <Box sx={{ marginBottom: '1.5rem' }}>
+          <Box display="flex" alignItems="center" justifyContent="space-between">
+            <Box display="flex" alignItems="center" gap="0.5rem">
+              <Typography>Connect Bluesky</Typography>
+              {blueskyConnected ? <CheckCircleIcon color="success" /> : <></>}
+            </Box>
+            {blueskyConnected ? (
+              <Button variant="contained" color="error">
+                Disconnect
+              </Button>
+            ) : (
+              <Button variant="contained" onClick={handleBlueskyConnect}>
+                Connect
+              </Button>
+            )}
+          </Box>
+          {!blueskyConnected && (
+            <>
+              <TextField
+                fullWidth
+                size="small"
+                placeholder="you.bsky.social"
+                value={blueskyHandle}
+                onChange={(e) => setBlueskyHandle(e.target.value)}
+                sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+              />
+              <TextField
+                fullWidth
+                size="small"
+                type="password"
+                placeholder="App password"
+                value={blueskyAppPassword}
+                onChange={(e) => setBlueskyAppPassword(e.target.value)}
+                sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+              />
+            </>
+          )}
+        </Box>
+
+        {/* Discord / Slack Webhook */}
+        <Box sx={{ marginBottom: '1.5rem' }}>
+          <Box display="flex" alignItems="center" justifyContent="space-between">
+            <Box display="flex" alignItems="center" gap="0.5rem">
+              <Typography>Discord / Slack Webhook</Typography>
+              {webhookConnected ? <CheckCircleIcon color="success" /> : <></>}
+            </Box>
+            {webhookConnected ? (
+              <Box display="flex" gap="0.5rem">
+                <Button variant="contained" onClick={handleWebhookTest}>
+                  Test
+                </Button>
+                <Button variant="contained" color="error" onClick={() => handleWebhookSave('')}>
+                  Remove
+                </Button>
+              </Box>
+            ) : (
+              <Button variant="contained" onClick={() => handleWebhookSave(webhookUrl)} disabled={!webhookUrl}>
+                Save
+              </Button>
+            )}
+          </Box>
+          {!webhookConnected && (
+            <TextField
+              fullWidth
+              size="small"
+              placeholder="https://discord.com/api/webhooks/..."
+              value={webhookUrl}
+              onChange={(e) => setWebhookUrl(e.target.value)}
+              sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+            />
+          )}
+        </Box>
+
+        {/* Telegram Connect */}
+        <Box sx={{ marginBottom: '1.5rem' }}>
+          <Box display="flex" alignItems="center" justifyContent="space-between">
+            <Box display="flex" alignItems="center" gap="0.5rem">
+              <Typography>Connect Telegram</Typography>
+              {telegramConnected ? <CheckCircleIcon color="success" /> : <></>}
+            </Box>
+            {telegramConnected ? (
+              <Button variant="contained" color="error">
+                Disconnect
+              </Button>
+            ) : (
+              <Button variant="contained" onClick={handleTelegramConnect}>
+                Connect
+              </Button>
+            )}
+          </Box>
+          {!telegramConnected && (
+            <>
+              <TextField
+                fullWidth
+                size="small"
+                type="password"
+                placeholder="Bot token from @BotFather"
+                value={telegramBotToken}
+                onChange={(e) => setTelegramBotToken(e.target.value)}
+                sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+              />
+              <TextField
+                fullWidth
+                size="small"
+                placeholder="@mychannel"
+                value={telegramChatId}
+                onChange={(e) => setTelegramChatId(e.target.value)}
+                sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+              />
+            </>
+          )}
+        </Box>
+
+        {/* Dev.to API Key, for cross-posting full articles */}
+        <Box sx={{ marginBottom: '1.5rem' }}>
+          <Box display="flex" alignItems="center" justifyContent="space-between">
+            <Box display="flex" alignItems="center" gap="0.5rem">
+              <Typography>Dev.to API Key</Typography>
+              {devtoConnected ? <CheckCircleIcon color="success" /> : <></>}
+            </Box>
+            {!devtoConnected && (
+              <Button variant="contained" onClick={handleDevtoConnect} disabled={!devtoApiKey}>
+                Connect
+              </Button>
+            )}
+          </Box>
+          {!devtoConnected && (
+            <TextField
+              fullWidth
+              size="small"
+              type="password"
+              placeholder="From dev.to/settings/extensions"
+              value={devtoApiKey}
+              onChange={(e) => setDevtoApiKey(e.target.value)}
+              sx={{ marginTop: '0.5rem', input: { color: 'white' } }}
+            />
+          )}
+        </Box>
+
         {/* Hashnode API Key */}
         <Box sx={{ marginBottom: '1.5rem' }}>
           <Box display="flex" alignItems="center" justifyContent="space-between">